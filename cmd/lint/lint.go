@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api/validation"
+	"github.com/johnbelamaric/k8srm-prototype/pkg/manifest"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	kjson "sigs.k8s.io/json"
+)
+
+// problem is something wrong with an object.
+type problem struct {
+	file string
+	kind string
+	name string
+
+	// field is the path to the field with the problem, such as
+	// spec.constraints.
+	field   string
+	message string
+
+	// silent is true if the object would be accepted anyway, but would be
+	// scheduled differently from what was intended, because the field is
+	// ignored.
+	silent bool
+}
+
+func (p problem) String() string {
+	// Errors from compiling constraints point at the problem on
+	// following lines, which are indented to keep them with this one.
+	message := strings.ReplaceAll(p.message, "\n", "\n    ")
+	s := fmt.Sprintf("%s: %s %s: %s: %s", p.file, p.kind, p.name, p.field, message)
+	if p.silent {
+		s += " (silently changes scheduling)"
+	}
+	return s
+}
+
+// object is an object loaded from a file, along with the problems found while
+// decoding it.
+type object struct {
+	file    string
+	kind    string
+	obj     any
+	unknown []string
+
+	// decodeErr is the problem with an object that could not be decoded,
+	// such as a field with the wrong type. Such an object is not checked
+	// any further, and obj is not set.
+	decodeErr *problem
+}
+
+// linter checks the objects in a set of files. The drivers are used to
+// type-check the constraints in classes; they are not checked themselves.
+type linter struct {
+	objects []object
+	drivers map[string]*api.DeviceDriver
+}
+
+func newLinter() *linter {
+	return &linter{drivers: make(map[string]*api.DeviceDriver)}
+}
+
+// load reads the objects in the given YAML files, and in the YAML files
+// directly within the given directories. Objects of other kinds are ignored.
+func (l *linter) load(paths []string) error {
+	return manifest.Load(paths, l.loadObject)
+}
+
+// pod is a Pod with the TypeMeta that api.Pod leaves out.
+type pod struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	api.Pod    `json:",inline"`
+}
+
+func (l *linter) loadObject(path string, j []byte) error {
+	var meta struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Metadata   struct {
+			Namespace string `json:"namespace"`
+			Name      string `json:"name"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(j, &meta); err != nil {
+		return err
+	}
+
+	var obj any
+	switch {
+	case meta.APIVersion == "v1" && meta.Kind == "Pod":
+		obj = &pod{}
+	case meta.APIVersion == api.DevMgmtAPIVersion && meta.Kind == "DeviceDriver":
+		obj = &api.DeviceDriver{}
+	case meta.APIVersion == api.DevMgmtAPIVersion && meta.Kind == "DeviceClass":
+		obj = &api.DeviceClass{}
+	case meta.APIVersion == api.DevMgmtAPIVersion && meta.Kind == "DeviceClaim":
+		obj = &api.DeviceClaim{}
+	case meta.APIVersion == api.DevMgmtAPIVersion && meta.Kind == "DevicePrivilegedClaim":
+		obj = &api.DevicePrivilegedClaim{}
+	default:
+		return nil
+	}
+
+	strictErrs, err := kjson.UnmarshalStrict(j, obj)
+	if err != nil {
+		name := meta.Metadata.Name
+		if meta.Metadata.Namespace != "" {
+			name = meta.Metadata.Namespace + "/" + name
+		}
+		p := decodeProblem(err)
+		p.file, p.kind, p.name = path, meta.Kind, name
+		l.objects = append(l.objects, object{file: path, kind: meta.Kind, decodeErr: &p})
+		return nil
+	}
+
+	if d, ok := obj.(*api.DeviceDriver); ok {
+		l.drivers[d.Name] = d
+		return nil
+	}
+
+	o := object{file: path, kind: meta.Kind, obj: obj}
+	for _, e := range strictErrs {
+		o.unknown = append(o.unknown, e.Error())
+	}
+	l.objects = append(l.objects, o)
+
+	return nil
+}
+
+// decodeProblem returns the problem for an error from decoding an object. The
+// API server would reject the object, so the problem is not silent.
+func decodeProblem(err error) problem {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return problem{field: typeErr.Field, message: fmt.Sprintf("Invalid type: got %s, expected %s", typeErr.Value, typeErr.Type)}
+	}
+
+	return problem{message: err.Error()}
+}
+
+// lint returns the problems with the loaded objects.
+func (l *linter) lint() []problem {
+	var problems []problem
+	for _, o := range l.objects {
+		if o.decodeErr != nil {
+			problems = append(problems, *o.decodeErr)
+			continue
+		}
+
+		name, errs := l.validate(o.obj)
+
+		for _, u := range o.unknown {
+			p := problem{file: o.file, kind: o.kind, name: name, message: u}
+			if m := unknownFieldRE.FindStringSubmatch(u); m != nil {
+				if o.kind == "Pod" && !strings.HasPrefix(m[1], "spec.deviceClaims") {
+					// Only the device claims of a pod are
+					// modeled; the rest is left to core/v1.
+					continue
+				}
+
+				// An unknown field is dropped, both here and
+				// by the API server. If the object is valid
+				// without it, nothing says that it was ignored.
+				p.field = m[1]
+				p.message = "unknown field"
+				p.silent = len(errs) == 0 && strings.HasPrefix(p.field, "spec.")
+				if s := suggestField(reflect.TypeOf(o.obj), m[1]); s != "" {
+					p.message += fmt.Sprintf("; did you mean %q?", s)
+				}
+			}
+			problems = append(problems, p)
+		}
+
+		for _, e := range errs {
+			problems = append(problems, problem{file: o.file, kind: o.kind, name: name, field: e.Field, message: e.ErrorBody()})
+		}
+	}
+
+	return problems
+}
+
+// validate validates the object in the same way as the API server, and
+// returns its name.
+func (l *linter) validate(obj any) (string, field.ErrorList) {
+	switch o := obj.(type) {
+	case *api.DeviceClass:
+		return o.Name, validation.ValidateDeviceClass(o, l.drivers[o.Spec.Driver])
+	case *api.DeviceClaim:
+		return o.Namespace + "/" + o.Name, validation.ValidateDeviceClaim(o)
+	case *api.DevicePrivilegedClaim:
+		return o.Namespace + "/" + o.Name, validation.ValidateDevicePrivilegedClaim(o)
+	case *pod:
+		return o.Namespace + "/" + o.Name, validatePodDeviceClaims(o.Spec.DeviceClaims)
+	}
+
+	return "", nil
+}
+
+// validatePodDeviceClaims validates the claims embedded in a pod. The rest of
+// the pod is not checked.
+func validatePodDeviceClaims(claims []api.PodDeviceClaim) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, pdc := range claims {
+		if pdc.Claim == nil {
+			continue
+		}
+
+		spec := api.DeviceClaimSpec{
+			MatchAttributes:    pdc.Claim.MatchAttributes,
+			DistinctAttributes: pdc.Claim.DistinctAttributes,
+			Claims:             pdc.Claim.Claims,
+		}
+		allErrs = append(allErrs, validation.ValidateDeviceClaimSpec(&spec, field.NewPath("spec", "deviceClaims").Index(i).Child("claim"))...)
+	}
+
+	return allErrs
+}
+
+var unknownFieldRE = regexp.MustCompile(`^unknown field "(.*)"$`)
+
+var indexRE = regexp.MustCompile(`\[[0-9]+\]`)
+
+// suggestField returns the name of a field that is similar to the unknown
+// field at the given path in the type, or "" if there is none.
+func suggestField(t reflect.Type, path string) string {
+	parts := strings.Split(indexRE.ReplaceAllString(path, ""), ".")
+	for _, part := range parts[:len(parts)-1] {
+		t = fieldType(t, part)
+		if t == nil {
+			return ""
+		}
+	}
+
+	unknown := parts[len(parts)-1]
+	best, bestDist := "", 3
+	for _, name := range jsonFields(t) {
+		if d := editDistance(strings.ToLower(unknown), strings.ToLower(name)); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+
+	return best
+}
+
+// fieldType returns the type of the field with the given JSON name, looking
+// through pointers, slices and maps.
+func fieldType(t reflect.Type, name string) reflect.Type {
+	t = elem(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.Anonymous && jsonName == "" {
+			if ft := fieldType(f.Type, name); ft != nil {
+				return ft
+			}
+			continue
+		}
+		if jsonName == name {
+			return f.Type
+		}
+	}
+
+	return nil
+}
+
+// jsonFields returns the JSON names of the fields of a struct type.
+func jsonFields(t reflect.Type) []string {
+	t = elem(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch {
+		case f.Anonymous && jsonName == "":
+			names = append(names, jsonFields(f.Type)...)
+		case jsonName != "" && jsonName != "-":
+			names = append(names, jsonName)
+		}
+	}
+
+	return names
+}
+
+func elem(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	return t
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"github.com/stretchr/testify/require"
+)
+
+// lintProblem is the part of a problem that the tests check.
+type lintProblem struct {
+	name    string
+	field   string
+	message string
+	silent  bool
+}
+
+func lintFiles(t *testing.T, paths ...string) []lintProblem {
+	l := newLinter()
+	require.NoError(t, l.load(paths))
+
+	var result []lintProblem
+	for _, p := range l.lint() {
+		result = append(result, lintProblem{name: p.name, field: p.field, message: p.message, silent: p.silent})
+	}
+	return result
+}
+
+func TestLintClasses(t *testing.T) {
+	problems := lintFiles(t, "../../testdata/classes.yaml")
+
+	var silent []lintProblem
+	for _, p := range problems {
+		if p.silent {
+			silent = append(silent, p)
+		}
+	}
+
+	// These classes are accepted, but do not do what they say.
+	require.Equal(t, []lintProblem{
+		{name: "example.com-foozer-single", field: "spec.maxDeviceCount", message: "unknown field", silent: true},
+		{name: "example.com-barzer-gpu-single", field: "spec.deviceMaxCount", message: "unknown field", silent: true},
+	}, silent)
+
+	// These ones are rejected, so the problems are not silent.
+	require.Contains(t, problems, lintProblem{name: "example.com-gpu-set", field: "spec.contstraints", message: `unknown field; did you mean "constraints"?`})
+	require.Contains(t, problems, lintProblem{name: "example.com-gpu-set", field: "spec.driver", message: "Required value"})
+	require.Contains(t, problems, lintProblem{name: "sriov-nic", field: "spec.deviceTypes", message: `unknown field; did you mean "deviceType"?`})
+	require.Contains(t, problems, lintProblem{name: "sriov-nic", field: "spec.deviceType", message: "Required value"})
+
+	var constraints []string
+	for _, p := range problems {
+		if p.field == "spec.constraints" {
+			constraints = append(constraints, p.name)
+			require.Contains(t, p.message, "Syntax error")
+		}
+	}
+	require.Equal(t, []string{"sriov-nic-1Gbps", "sriov-nic-10Gbps"}, constraints)
+}
+
+func TestLintFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	testCases := map[string]struct {
+		content string
+		expect  []lintProblem
+	}{
+		"valid claim": {
+			content: `
+apiVersion: devmgmtproto.k8s.io/v1alpha1
+kind: DeviceClaim
+metadata:
+  namespace: default
+  name: claim
+spec:
+  claims:
+  - deviceType: gpu
+    constraints: "device.model == 'foozer-1000'"
+`,
+		},
+		"claim": {
+			content: `
+apiVersion: devmgmtproto.k8s.io/v1alpha1
+kind: DeviceClaim
+metadata:
+  namespace: default
+  name: claim
+spec:
+  claims:
+  - deviceType: gpu
+    constraint: "device.model == 'foozer-1000'"
+`,
+			expect: []lintProblem{
+				{name: "default/claim", field: "spec.claims[0].constraint", message: `unknown field; did you mean "constraints"?`, silent: true},
+			},
+		},
+		"class typed by driver": {
+			content: `
+apiVersion: devmgmtproto.k8s.io/v1alpha1
+kind: DeviceDriver
+metadata:
+  name: example.com-foozer
+spec:
+  deviceTypes: [gpu]
+  attributes:
+  - name: model
+    type: string
+---
+apiVersion: devmgmtproto.k8s.io/v1alpha1
+kind: DeviceClass
+metadata:
+  name: foozer
+spec:
+  deviceType: gpu
+  driver: example.com-foozer
+  constraints: "device.modle == 'foozer-1000'"
+`,
+			expect: []lintProblem{
+				{name: "foozer", field: "spec.constraints", message: `Invalid value: "device.modle == 'foozer-1000'": attributes of driver "example.com-foozer": ERROR: <input>:1:7: undefined field 'modle'
+ | device.modle == 'foozer-1000'
+ | ......^`},
+			},
+		},
+		"pod": {
+			content: `
+apiVersion: v1
+kind: Pod
+metadata:
+  namespace: default
+  name: pod
+spec:
+  containers:
+  - name: main
+    image: registry.k8s.io/pause:3.9
+    devices:
+    - name: gpu
+  deviceClaims:
+  - name: gpu
+    claim:
+      claims:
+      - deviceType: gpu
+        matchAttribute: [model]
+`,
+			// The per-container references are not modeled.
+			expect: []lintProblem{
+				{name: "default/pod", field: "spec.deviceClaims[0].claim.claims[0].matchAttribute", message: `unknown field; did you mean "matchAttributes"?`, silent: true},
+			},
+		},
+		"wrong type": {
+			content: `
+apiVersion: devmgmtproto.k8s.io/v1alpha1
+kind: DeviceClaim
+metadata:
+  namespace: default
+  name: bad
+spec:
+  claims:
+  - deviceType: gpu
+    constraints: 5
+---
+apiVersion: devmgmtproto.k8s.io/v1alpha1
+kind: DeviceClaim
+metadata:
+  namespace: default
+  name: claim
+spec:
+  claims:
+  - deviceType: gpu
+    constraint: "device.model == 'foozer-1000'"
+---
+apiVersion: devmgmtproto.k8s.io/v1alpha1
+kind: DeviceClass
+metadata:
+  name: class
+spec:
+  deviceType: gpu
+  driver: [example.com-foozer]
+`,
+			// The objects after the one that cannot be decoded are
+			// still checked.
+			expect: []lintProblem{
+				{name: "default/bad", field: "spec.claims.constraints", message: "Invalid type: got number, expected string"},
+				{name: "default/claim", field: "spec.claims[0].constraint", message: `unknown field; did you mean "constraints"?`, silent: true},
+				{name: "class", field: "spec.driver", message: "Invalid type: got array, expected string"},
+			},
+		},
+		"other kinds": {
+			content: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  foo: bar
+`,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			require.Equal(t, tc.expect, lintFiles(t, write("objects.yaml", tc.content)))
+		})
+	}
+}
+
+func TestSuggestField(t *testing.T) {
+	class := reflect.TypeOf(&api.DeviceClass{})
+	require.Equal(t, "constraints", suggestField(class, "spec.contstraints"))
+	require.Equal(t, "deviceType", suggestField(class, "spec.deviceTypes"))
+	require.Equal(t, "", suggestField(class, "spec.maxDeviceCount"))
+	require.Equal(t, "namespace", suggestField(class, "metadata.namespce"))
+	require.Equal(t, "", suggestField(class, "nosuch.field"))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func init() {
+	flag.Usage = usage
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "usage: %s <file-or-dir> ...\n", os.Args[0])
+	fmt.Fprintf(flag.CommandLine.Output(), "\nChecks the DeviceClasses, DeviceClaims, DevicePrivilegedClaims and Pods in the\n")
+	fmt.Fprintf(flag.CommandLine.Output(), "YAML files for unknown fields and invalid constraints. DeviceDrivers in the\n")
+	fmt.Fprintf(flag.CommandLine.Output(), "files are used to type-check the constraints of their classes.\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 1 {
+		usage()
+		os.Exit(1)
+	}
+
+	l := newLinter()
+	if err := l.load(args); err != nil {
+		fmt.Fprintf(os.Stderr, "error loading files: %v\n", err)
+		os.Exit(1)
+	}
+
+	problems := l.lint()
+	silent := 0
+	for _, p := range problems {
+		fmt.Println(p)
+		if p.silent {
+			silent++
+		}
+	}
+
+	if len(problems) == 0 {
+		return
+	}
+
+	fmt.Printf("\n%d problems, %d of which silently change scheduling: the objects are accepted, but the fields are ignored\n", len(problems), silent)
+	os.Exit(1)
+}
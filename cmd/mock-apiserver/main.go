@@ -1,16 +1,23 @@
 package main
 
 import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"log"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/mockkubeapiserver"
-	"sync"
 )
 
 func main() {
 	var wg sync.WaitGroup
 
+	crdDir := flag.String("crds", "config/crd", "directory of the CustomResourceDefinitions for the device management kinds")
+	flag.Parse()
+
 	k8s, err := mockkubeapiserver.NewMockKubeAPIServer(":55441")
 	if err != nil {
 		log.Fatalf("error creating mock-apiserver: %v", err)
@@ -22,11 +29,12 @@ func main() {
 	k8s.RegisterType(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"}, "pods", meta.RESTScopeNamespace)
 	k8s.RegisterType(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Node"}, "nodes", meta.RESTScopeNamespace)
 	k8s.RegisterType(schema.GroupVersionKind{Group: "foozer.example.com", Version: "v1alpha1", Kind: "FoozerConfig"}, "foozerconfigs", meta.RESTScopeNamespace)
-	k8s.RegisterType(schema.GroupVersionKind{Group: "devmgmtproto.k8s.io", Version: "v1alpha1", Kind: "DeviceDriver"}, "devicedrivers", meta.RESTScopeRoot)
-	k8s.RegisterType(schema.GroupVersionKind{Group: "devmgmtproto.k8s.io", Version: "v1alpha1", Kind: "DeviceClass"}, "deviceclasses", meta.RESTScopeRoot)
-	k8s.RegisterType(schema.GroupVersionKind{Group: "devmgmtproto.k8s.io", Version: "v1alpha1", Kind: "DeviceClaim"}, "deviceclaims", meta.RESTScopeNamespace)
-	k8s.RegisterType(schema.GroupVersionKind{Group: "devmgmtproto.k8s.io", Version: "v1alpha1", Kind: "DevicePrivilegedClaim"}, "deviceprivilegedclaims", meta.RESTScopeNamespace)
-	k8s.RegisterType(schema.GroupVersionKind{Group: "devmgmtproto.k8s.io", Version: "v1alpha1", Kind: "DevicePool"}, "devicepools", meta.RESTScopeRoot)
+
+	// The device management kinds are registered by creating their CRDs,
+	// as they would be in a real cluster.
+	if err := addCRDs(k8s, *crdDir); err != nil {
+		log.Fatalf("error adding CRDs: %v", err)
+	}
 
 	wg.Add(1)
 	addr, err := k8s.StartServing()
@@ -37,3 +45,26 @@ func main() {
 
 	wg.Wait()
 }
+
+// addCRDs creates the CustomResourceDefinitions in the YAML files in dir.
+func addCRDs(k8s *mockkubeapiserver.MockKubeAPIServer, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return os.ErrNotExist
+	}
+
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		if err := k8s.AddObjectsFromManifest(string(b)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
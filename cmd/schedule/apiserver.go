@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"github.com/johnbelamaric/k8srm-prototype/pkg/client"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	podGVK = corev1.SchemeGroupVersion.WithKind("Pod")
+	podGVR = corev1.SchemeGroupVersion.WithResource("pods")
+)
+
+// apiServer reads and writes the objects needed for scheduling. Pods are
+// accessed with the dynamic client, since the device claim fields are not
+// part of the core/v1 Pod type.
+type apiServer struct {
+	client  client.Interface
+	dynamic dynamic.Interface
+
+	// explain turns on explain mode in the scheduler.
+	explain bool
+}
+
+func (s *apiServer) getPod(ctx context.Context, namespace, name string) (*api.Pod, error) {
+	u, err := s.dynamic.Resource(podGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	pod := &api.Pod{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, pod); err != nil {
+		return nil, fmt.Errorf("could not convert pod %s/%s: %w", namespace, name, err)
+	}
+
+	return pod, nil
+}
+
+func (s *apiServer) getClusterState(ctx context.Context) (clusterState, error) {
+	cs := clusterState{explain: s.explain}
+
+	drivers, err := s.client.DeviceDrivers().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return cs, fmt.Errorf("could not list device drivers: %w", err)
+	}
+	cs.drivers = drivers.Items
+
+	classes, err := s.client.DeviceClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return cs, fmt.Errorf("could not list device classes: %w", err)
+	}
+	cs.classes = classes.Items
+
+	pools, err := s.client.DevicePools().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return cs, fmt.Errorf("could not list device pools: %w", err)
+	}
+	cs.pools = pools.Items
+
+	claims, err := s.client.DeviceClaims(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return cs, fmt.Errorf("could not list device claims: %w", err)
+	}
+	cs.claims = claims.Items
+
+	return cs, nil
+}
+
+// schedulePod schedules a pod that is stored in the API server. It creates any
+// claims generated for the pod, records the allocations and the pod name in
+// the status of each claim, and then binds the pod to the selected node. If a
+// claim cannot be updated or the pod cannot be bound, the claims already
+// updated are rolled back, so that their devices are not held for a pod that
+// was never bound.
+func (s *apiServer) schedulePod(ctx context.Context, namespace, name string) (*podResult, error) {
+	pod, err := s.getPod(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if pod.Spec.NodeName != "" {
+		return nil, fmt.Errorf("pod %s/%s is already bound to node %q", namespace, name, pod.Spec.NodeName)
+	}
+
+	claims, err := resolvePodClaims(pod, func(claimName string) (*api.DeviceClaim, error) {
+		return s.client.DeviceClaims(namespace).Get(ctx, claimName, metav1.GetOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := s.getClusterState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := selectNodeForPod(pod, claims, cs)
+	if err != nil {
+		return result, err
+	}
+
+	var updated []podClaim
+	for _, pc := range claims {
+		if err := s.updateClaim(ctx, pod, pc, result.allocations[pc.claim.Name]); err != nil {
+			return result, s.rollbackClaims(ctx, pod, updated, err)
+		}
+		updated = append(updated, pc)
+	}
+
+	if err := s.bindPod(ctx, namespace, name, result.nodeName); err != nil {
+		err = fmt.Errorf("could not bind pod %s/%s to node %q: %w", namespace, name, result.nodeName, err)
+		return result, s.rollbackClaims(ctx, pod, updated, err)
+	}
+
+	return result, nil
+}
+
+// bindPod binds the pod to the node with the binding subresource, as a
+// scheduler does. Servers without the subresource, such as the mock API
+// server, get the node name of the pod updated instead.
+func (s *apiServer) bindPod(ctx context.Context, namespace, name, nodeName string) error {
+	binding := &corev1.Binding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Binding"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Target:     corev1.ObjectReference{Kind: "Node", Name: nodeName},
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(binding)
+	if err != nil {
+		return err
+	}
+
+	pods := s.dynamic.Resource(podGVR).Namespace(namespace)
+	_, err = pods.Create(ctx, &unstructured.Unstructured{Object: obj}, metav1.CreateOptions{}, "binding")
+	if !apierrors.IsNotFound(err) && !apierrors.IsMethodNotSupported(err) {
+		return err
+	}
+
+	return s.updatePodNodeName(ctx, namespace, name, nodeName)
+}
+
+// updatePodNodeName sets the node name of the pod. The mock API server does
+// not support patching pods with fields it does not know about, such as
+// deviceClaims, so the whole pod is updated. A real API server only allows
+// this for a pod that is being created, so the binding subresource must be
+// used there instead.
+func (s *apiServer) updatePodNodeName(ctx context.Context, namespace, name, nodeName string) error {
+	pods := s.dynamic.Resource(podGVR).Namespace(namespace)
+	u, err := pods.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if err := unstructured.SetNestedField(u.Object, nodeName, "spec", "nodeName"); err != nil {
+		return err
+	}
+
+	_, err = pods.Update(ctx, u, metav1.UpdateOptions{})
+	return err
+}
+
+// updateClaim creates the claim if needed, and then writes the new
+// allocations, if any, and the pod name to its status.
+func (s *apiServer) updateClaim(ctx context.Context, pod *api.Pod, pc podClaim, allocations []api.DeviceAllocation) error {
+	claims := s.client.DeviceClaims(pod.Namespace)
+	claim := pc.claim.DeepCopy()
+
+	if pc.create {
+		created, err := claims.Create(ctx, claim, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("could not create device claim %q: %w", claim.Name, err)
+		}
+		claim = created
+	}
+
+	if len(allocations) > 0 {
+		claim.Status.Allocations = allocations
+	}
+
+	found := false
+	for _, n := range claim.Status.PodNames {
+		if n == pod.Name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		claim.Status.PodNames = append(claim.Status.PodNames, pod.Name)
+	}
+
+	if _, err := claims.UpdateStatus(ctx, claim, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("could not update status of device claim %q: %w", claim.Name, err)
+	}
+
+	return nil
+}
+
+// rollbackClaims undoes the updates made by updateClaim to the claims for a
+// pod that could not be bound. Claims that were created for the pod are
+// deleted, and the others get back their allocations from before and lose
+// the pod name. The returned error is the cause, along with any claims that
+// could not be rolled back.
+func (s *apiServer) rollbackClaims(ctx context.Context, pod *api.Pod, updated []podClaim, cause error) error {
+	var failed []string
+	for _, pc := range updated {
+		if err := s.rollbackClaim(ctx, pod, pc); err != nil {
+			failed = append(failed, fmt.Sprintf("%q (%v)", pc.claim.Name, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%w; could not roll back device claims %s", cause, strings.Join(failed, ", "))
+	}
+
+	return cause
+}
+
+func (s *apiServer) rollbackClaim(ctx context.Context, pod *api.Pod, pc podClaim) error {
+	claims := s.client.DeviceClaims(pod.Namespace)
+	if pc.create {
+		return claims.Delete(ctx, pc.claim.Name, metav1.DeleteOptions{})
+	}
+
+	claim, err := claims.Get(ctx, pc.claim.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	claim.Status.Allocations = pc.claim.Status.Allocations
+	var podNames []string
+	for _, n := range claim.Status.PodNames {
+		if n != pod.Name {
+			podNames = append(podNames, n)
+		}
+	}
+	claim.Status.PodNames = podNames
+
+	_, err = claims.UpdateStatus(ctx, claim, metav1.UpdateOptions{})
+	return err
+}
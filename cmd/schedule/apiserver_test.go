@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"github.com/johnbelamaric/k8srm-prototype/pkg/client"
+	"github.com/johnbelamaric/k8srm-prototype/pkg/gen"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/mockkubeapiserver"
+)
+
+// startServer starts a mock API server loaded with the drivers, classes and
+// pools, plus the objects in the given testdata files.
+func startServer(t *testing.T, pools []api.DevicePool, files ...string) *apiServer {
+	k8s, err := mockkubeapiserver.NewMockKubeAPIServer(":0")
+	require.NoError(t, err)
+
+	k8s.RegisterType(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "ConfigMap"}, "configmaps", meta.RESTScopeNamespace)
+	k8s.RegisterType(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"}, "pods", meta.RESTScopeNamespace)
+	k8s.RegisterType(schema.GroupVersionKind{Group: "foozer.example.com", Version: "v1alpha1", Kind: "FoozerConfig"}, "foozerconfigs", meta.RESTScopeNamespace)
+	k8s.RegisterType(api.SchemeGroupVersion.WithKind("DeviceDriver"), "devicedrivers", meta.RESTScopeRoot)
+	k8s.RegisterType(api.SchemeGroupVersion.WithKind("DeviceClass"), "deviceclasses", meta.RESTScopeRoot)
+	k8s.RegisterType(api.SchemeGroupVersion.WithKind("DeviceClaim"), "deviceclaims", meta.RESTScopeNamespace)
+	k8s.RegisterType(api.SchemeGroupVersion.WithKind("DevicePool"), "devicepools", meta.RESTScopeRoot)
+
+	for _, f := range append([]string{"drivers.yaml", "classes.yaml"}, files...) {
+		b, err := os.ReadFile("../../testdata/" + f)
+		require.NoError(t, err)
+		require.NoError(t, k8s.AddObjectsFromManifest(string(b)), f)
+	}
+
+	addr, err := k8s.StartServing()
+	require.NoError(t, err)
+	t.Cleanup(func() { k8s.Stop() })
+
+	config := &rest.Config{Host: "http://" + addr.String()}
+	cs, err := client.NewForConfig(config)
+	require.NoError(t, err)
+	dc, err := dynamic.NewForConfig(config)
+	require.NoError(t, err)
+
+	for i := range pools {
+		_, err := cs.DevicePools().Create(context.Background(), &pools[i], metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	return &apiServer{client: cs, dynamic: dc}
+}
+
+func TestSchedulePod(t *testing.T) {
+	testCases := map[string]struct {
+		file        string
+		pod         string
+		claim       string
+		expectNode  string
+		expectOwned bool
+	}{
+		"claim name": {
+			file:       "pod-ref-foozer-single.yaml",
+			pod:        "ref-foozer-claim",
+			claim:      "example.com-foozer-single-superfast-claim",
+			expectNode: "foozer-1000-small-00",
+		},
+		"claim template name": {
+			file:        "pod-template-foozer-single.yaml",
+			pod:         "template-foozer-claim",
+			claim:       "template-foozer-claim-foozer-gpu",
+			expectNode:  "foozer-1000-small-00",
+			expectOwned: true,
+		},
+		"embedded claim": {
+			file:        "pod-embedded-foozer-single.yaml",
+			pod:         "embedded-foozer-claim",
+			claim:       "embedded-foozer-claim-foozer-gpu",
+			expectNode:  "foozer-1000-small-00",
+			expectOwned: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ctx := context.Background()
+			s := startServer(t, gen.Gen("foozer-1000-small", 2), tc.file)
+
+			result, err := s.schedulePod(ctx, "default", tc.pod)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectNode, result.nodeName)
+
+			pod, err := s.getPod(ctx, "default", tc.pod)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectNode, pod.Spec.NodeName)
+			require.Len(t, pod.Spec.DeviceClaims, 1)
+
+			claim, err := s.client.DeviceClaims("default").Get(ctx, tc.claim, metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, []string{tc.pod}, claim.Status.PodNames)
+			require.Len(t, claim.Status.Allocations, 1)
+			require.Equal(t, tc.expectNode+"-foozer", claim.Status.Allocations[0].DevicePoolName)
+			require.Equal(t, tc.expectOwned, metav1.IsControlledBy(claim, pod))
+
+			_, err = s.schedulePod(ctx, "default", tc.pod)
+			require.ErrorContains(t, err, "already bound")
+		})
+	}
+}
+
+func TestSchedulePodSharedClaim(t *testing.T) {
+	ctx := context.Background()
+	s := startServer(t, gen.Gen("foozer-1000-small", 2), "pod-ref-foozer-single.yaml")
+
+	// The first pod allocates the claim on the first node.
+	_, err := s.schedulePod(ctx, "default", "ref-foozer-claim")
+	require.NoError(t, err)
+
+	// Another pod sharing the claim must go to the same node, even when
+	// that is not the one that would otherwise be chosen.
+	claim, err := s.client.DeviceClaims("default").Get(ctx, "example.com-foozer-single-superfast-claim", metav1.GetOptions{})
+	require.NoError(t, err)
+	claim.Status.Allocations = []api.DeviceAllocation{{DevicePoolName: "foozer-1000-small-01-foozer", DeviceName: "dev-02"}}
+	_, err = s.client.DeviceClaims("default").UpdateStatus(ctx, claim, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	pod, err := s.dynamic.Resource(podGVR).Namespace("default").Get(ctx, "ref-foozer-claim", metav1.GetOptions{})
+	require.NoError(t, err)
+	pod.SetName("another-ref-foozer-claim")
+	pod.SetResourceVersion("")
+	pod.SetUID("")
+	unstructured.RemoveNestedField(pod.Object, "spec", "nodeName")
+	_, err = s.dynamic.Resource(podGVR).Namespace("default").Create(ctx, pod, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	result, err := s.schedulePod(ctx, "default", "another-ref-foozer-claim")
+	require.NoError(t, err)
+	require.Equal(t, "foozer-1000-small-01", result.nodeName)
+	require.Empty(t, result.allocations)
+
+	claim, err = s.client.DeviceClaims("default").Get(ctx, "example.com-foozer-single-superfast-claim", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"ref-foozer-claim", "another-ref-foozer-claim"}, claim.Status.PodNames)
+	require.Equal(t, "dev-02", claim.Status.Allocations[0].DeviceName)
+}
+
+func TestSchedulePodAllocatedDevices(t *testing.T) {
+	ctx := context.Background()
+	s := startServer(t, gen.Gen("foozer-1000-small", 1), "pod-template-foozer-single.yaml")
+
+	// Another claim already holds the first three devices.
+	other := &api.DeviceClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"},
+		Spec: api.DeviceClaimSpec{
+			Claims: []api.DeviceClaimInstance{
+				{DeviceClaimDetail: api.DeviceClaimDetail{DeviceType: ptr("gpu")}},
+			},
+		},
+	}
+	other, err := s.client.DeviceClaims("other").Create(ctx, other, metav1.CreateOptions{})
+	require.NoError(t, err)
+	other.Status.Allocations = []api.DeviceAllocation{
+		{DevicePoolName: "foozer-1000-small-00-foozer", DeviceName: "dev-00"},
+		{DevicePoolName: "foozer-1000-small-00-foozer", DeviceName: "dev-01"},
+		{DevicePoolName: "foozer-1000-small-00-foozer", DeviceName: "dev-02"},
+	}
+	_, err = s.client.DeviceClaims("other").UpdateStatus(ctx, other, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	result, err := s.schedulePod(ctx, "default", "template-foozer-claim")
+	require.NoError(t, err)
+	require.Equal(t, []api.DeviceAllocation{{DevicePoolName: "foozer-1000-small-00-foozer", DeviceName: "dev-03"}}, result.allocations["template-foozer-claim-foozer-gpu"])
+}
+
+func TestSchedulePodUnknownClass(t *testing.T) {
+	ctx := context.Background()
+	s := startServer(t, gen.Gen("foozer-1000-small", 1), "pod-template-foozer-single.yaml")
+	require.NoError(t, s.client.DeviceClasses().Delete(ctx, "example.com-foozer-single", metav1.DeleteOptions{}))
+
+	_, err := s.schedulePod(ctx, "default", "template-foozer-claim")
+	require.ErrorContains(t, err, `device class "example.com-foozer-single" not found`)
+
+	pod, err := s.getPod(ctx, "default", "template-foozer-claim")
+	require.NoError(t, err)
+	require.Empty(t, pod.Spec.NodeName)
+}
+
+// bindingClient is a dynamic client that handles the binding subresource of
+// pods, which the mock API server does not support, with the bind function.
+type bindingClient struct {
+	dynamic.Interface
+	bind func(binding *unstructured.Unstructured) error
+}
+
+func (c bindingClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return bindingResource{NamespaceableResourceInterface: c.Interface.Resource(resource), bind: c.bind}
+}
+
+type bindingResource struct {
+	dynamic.NamespaceableResourceInterface
+	bind func(binding *unstructured.Unstructured) error
+}
+
+func (r bindingResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return bindingNamespace{ResourceInterface: r.NamespaceableResourceInterface.Namespace(namespace), bind: r.bind}
+}
+
+type bindingNamespace struct {
+	dynamic.ResourceInterface
+	bind func(binding *unstructured.Unstructured) error
+}
+
+func (n bindingNamespace) Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if len(subresources) == 1 && subresources[0] == "binding" {
+		return obj, n.bind(obj)
+	}
+	return n.ResourceInterface.Create(ctx, obj, options, subresources...)
+}
+
+func TestSchedulePodBinding(t *testing.T) {
+	ctx := context.Background()
+	s := startServer(t, gen.Gen("foozer-1000-small", 1), "pod-template-foozer-single.yaml")
+
+	var bound []string
+	s.dynamic = bindingClient{Interface: s.dynamic, bind: func(binding *unstructured.Unstructured) error {
+		node, _, _ := unstructured.NestedString(binding.Object, "target", "name")
+		bound = append(bound, binding.GetNamespace()+"/"+binding.GetName()+" "+node)
+		return nil
+	}}
+
+	_, err := s.schedulePod(ctx, "default", "template-foozer-claim")
+	require.NoError(t, err)
+	require.Equal(t, []string{"default/template-foozer-claim foozer-1000-small-00"}, bound)
+
+	// The pod is left to the binding, rather than being updated.
+	pod, err := s.getPod(ctx, "default", "template-foozer-claim")
+	require.NoError(t, err)
+	require.Empty(t, pod.Spec.NodeName)
+}
+
+func TestSchedulePodBindingFailed(t *testing.T) {
+	testCases := map[string]struct {
+		file          string
+		pod           string
+		claim         string
+		expectDeleted bool
+	}{
+		// The existing claim is left as it was.
+		"claim name": {
+			file:  "pod-ref-foozer-single.yaml",
+			pod:   "ref-foozer-claim",
+			claim: "example.com-foozer-single-superfast-claim",
+		},
+		// The claim created for the pod is deleted.
+		"claim template name": {
+			file:          "pod-template-foozer-single.yaml",
+			pod:           "template-foozer-claim",
+			claim:         "template-foozer-claim-foozer-gpu",
+			expectDeleted: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ctx := context.Background()
+			s := startServer(t, gen.Gen("foozer-1000-small", 1), tc.file)
+			s.dynamic = bindingClient{Interface: s.dynamic, bind: func(*unstructured.Unstructured) error {
+				return apierrors.NewForbidden(podGVR.GroupResource(), tc.pod, nil)
+			}}
+
+			_, err := s.schedulePod(ctx, "default", tc.pod)
+			require.ErrorContains(t, err, "could not bind pod default/"+tc.pod)
+
+			claim, err := s.client.DeviceClaims("default").Get(ctx, tc.claim, metav1.GetOptions{})
+			if tc.expectDeleted {
+				require.True(t, apierrors.IsNotFound(err), "unexpected error: %v", err)
+				return
+			}
+			require.NoError(t, err)
+			require.Empty(t, claim.Status.Allocations)
+			require.Empty(t, claim.Status.PodNames)
+		})
+	}
+}
+
+func ptr[T any](val T) *T {
+	var v T = val
+	return &v
+}
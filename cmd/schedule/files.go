@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"github.com/johnbelamaric/k8srm-prototype/pkg/client"
+	"github.com/johnbelamaric/k8srm-prototype/pkg/manifest"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// fileCluster is an in-memory cluster loaded from YAML files, which is used
+// to try out scheduling without an API server.
+type fileCluster struct {
+	clusterState
+	pods []api.Pod
+}
+
+// loadFiles reads the objects in the given YAML files, and in the YAML files
+// directly within the given directories. Each file may contain multiple
+// documents, and each document may be a single object, a List, or an array of
+// objects such as the output of cmd/gen. Objects of kinds not needed for
+// scheduling are ignored. If the same object appears more than once, the last
+// one wins.
+func loadFiles(paths []string) (*fileCluster, error) {
+	l := &loader{
+		decoder: client.Codecs.UniversalDecoder(api.SchemeGroupVersion),
+		objects: make(map[string]metav1.Object),
+	}
+
+	err := manifest.Load(paths, func(_ string, doc []byte) error {
+		return l.loadDocument(doc)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return l.cluster(), nil
+}
+
+type loader struct {
+	decoder runtime.Decoder
+
+	// objects holds the loaded objects by kind, namespace and name, and
+	// keys records the order in which they were first seen.
+	objects map[string]metav1.Object
+	keys    []string
+}
+
+// loadDocument loads the objects in the JSON form of a YAML document.
+func (l *loader) loadDocument(j []byte) error {
+	if j[0] == '[' {
+		var items []json.RawMessage
+		if err := json.Unmarshal(j, &items); err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := l.loadObject(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return l.loadObject(j)
+}
+
+func (l *loader) loadObject(j []byte) error {
+	var obj struct {
+		metav1.TypeMeta `json:",inline"`
+		Items           []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(j, &obj); err != nil {
+		return err
+	}
+
+	if strings.HasSuffix(obj.Kind, "List") {
+		for _, item := range obj.Items {
+			if err := l.loadObject(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch {
+	case obj.APIVersion == "v1" && obj.Kind == "Pod":
+		pod := &api.Pod{}
+		if err := json.Unmarshal(j, pod); err != nil {
+			return fmt.Errorf("pod: %w", err)
+		}
+		l.add(obj.Kind, pod)
+
+	case obj.APIVersion == api.DevMgmtAPIVersion:
+		decoded, _, err := l.decoder.Decode(j, nil, nil)
+		if err != nil {
+			return err
+		}
+		m, ok := decoded.(metav1.Object)
+		if !ok {
+			return fmt.Errorf("unexpected object of kind %s", obj.Kind)
+		}
+		l.add(obj.Kind, m)
+	}
+
+	return nil
+}
+
+func (l *loader) add(kind string, obj metav1.Object) {
+	key := kind + "/" + obj.GetNamespace() + "/" + obj.GetName()
+	if _, ok := l.objects[key]; !ok {
+		l.keys = append(l.keys, key)
+	}
+	l.objects[key] = obj
+}
+
+func (l *loader) cluster() *fileCluster {
+	c := &fileCluster{}
+	for _, key := range l.keys {
+		switch o := l.objects[key].(type) {
+		case *api.DeviceDriver:
+			c.drivers = append(c.drivers, *o)
+		case *api.DeviceClass:
+			c.classes = append(c.classes, *o)
+		case *api.DevicePool:
+			c.pools = append(c.pools, *o)
+		case *api.DeviceClaim:
+			c.claims = append(c.claims, *o)
+		case *api.Pod:
+			c.pods = append(c.pods, *o)
+		}
+	}
+
+	return c
+}
+
+// scheduleFromFiles schedules a pod in the in-memory cluster. It resolves the
+// pod's claims in the same way as when using the API server, but nothing is
+// created or updated.
+func scheduleFromFiles(c *fileCluster, namespace, name string) (*podResult, error) {
+	var pod *api.Pod
+	for i := range c.pods {
+		if c.pods[i].Namespace == namespace && c.pods[i].Name == name {
+			pod = &c.pods[i]
+			break
+		}
+	}
+
+	if pod == nil {
+		return nil, fmt.Errorf("pod %s/%s not found", namespace, name)
+	}
+
+	if pod.Spec.NodeName != "" {
+		return nil, fmt.Errorf("pod %s/%s is already bound to node %q", namespace, name, pod.Spec.NodeName)
+	}
+
+	claims, err := resolvePodClaims(pod, func(claimName string) (*api.DeviceClaim, error) {
+		for i := range c.claims {
+			if c.claims[i].Namespace == namespace && c.claims[i].Name == claimName {
+				return &c.claims[i], nil
+			}
+		}
+		return nil, errors.NewNotFound(api.Resource("deviceclaims"), claimName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return selectNodeForPod(pod, claims, c.clusterState)
+}
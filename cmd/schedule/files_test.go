@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"github.com/johnbelamaric/k8srm-prototype/pkg/gen"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/yaml"
+)
+
+// writePools writes pools as a YAML array, the same way cmd/gen does.
+func writePools(t *testing.T, pools []api.DevicePool) string {
+	b, err := yaml.Marshal(pools)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "pools.yaml")
+	require.NoError(t, os.WriteFile(path, b, 0644))
+	return path
+}
+
+func TestLoadFiles(t *testing.T) {
+	pools := writePools(t, gen.Gen("foozer-1000-small", 2))
+
+	list := filepath.Join(t.TempDir(), "list.yaml")
+	require.NoError(t, os.WriteFile(list, []byte(`apiVersion: v1
+kind: List
+items:
+- apiVersion: devmgmtproto.k8s.io/v1alpha1
+  kind: DeviceDriver
+  metadata:
+    name: example.com-bazzer
+  spec:
+    deviceTypes:
+    - gpu
+`), 0644))
+
+	c, err := loadFiles([]string{"../../testdata", pools, list})
+	require.NoError(t, err)
+
+	require.Len(t, c.drivers, 5)
+	require.Equal(t, "example.com-bazzer", c.drivers[4].Name)
+	require.NotEmpty(t, c.classes)
+	require.Len(t, c.pools, 2)
+	require.Len(t, c.pods, 3)
+
+	// The same claim appears in two files, but is only loaded once.
+	require.Len(t, c.claims, 1)
+	require.Equal(t, "example.com-foozer-single-superfast-claim", c.claims[0].Name)
+
+	// Defaults are applied as the objects are decoded.
+	count := c.claims[0].Spec.Claims[0].Requests["count"]
+	require.Equal(t, "1", count.String())
+}
+
+func TestScheduleFromFiles(t *testing.T) {
+	pools := writePools(t, gen.Gen("foozer-1000-small", 2))
+	c, err := loadFiles([]string{"../../testdata", pools})
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		pod           string
+		claim         string
+		expectNode    string
+		expectFailure string
+	}{
+		"claim name": {
+			pod:        "ref-foozer-claim",
+			claim:      "example.com-foozer-single-superfast-claim",
+			expectNode: "foozer-1000-small-00",
+		},
+		"claim template name": {
+			pod:        "template-foozer-claim",
+			claim:      "template-foozer-claim-foozer-gpu",
+			expectNode: "foozer-1000-small-00",
+		},
+		"embedded claim": {
+			pod:        "embedded-foozer-claim",
+			claim:      "embedded-foozer-claim-foozer-gpu",
+			expectNode: "foozer-1000-small-00",
+		},
+		"missing pod": {
+			pod:           "missing",
+			expectFailure: "pod default/missing not found",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			result, err := scheduleFromFiles(c, "default", tc.pod)
+			if tc.expectFailure != "" {
+				require.ErrorContains(t, err, tc.expectFailure)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expectNode, result.nodeName)
+			require.Len(t, result.nodeResults, 2)
+			require.Equal(t, []api.DeviceAllocation{{DevicePoolName: tc.expectNode + "-foozer", DeviceName: "dev-00"}}, result.allocations[tc.claim])
+		})
+	}
+
+	// Nothing is written back to the in-memory cluster.
+	require.Empty(t, c.claims[0].Status.Allocations)
+	require.Len(t, c.claims, 1)
+}
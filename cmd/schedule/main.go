@@ -1,24 +1,49 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
-	//"github.com/johnbelamaric/k8srm-prototype/pkg/api"
-	//"sigs.k8s.io/yaml"
+	"sort"
+	"strings"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/client"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"sigs.k8s.io/yaml"
 )
 
-var flagPodName, flagKubeconfig string
-var flagVerbose bool
+var flagPodName, flagKubeconfig, flagNamespace string
+var flagFiles fileList
+var flagVerbose, flagExplain bool
+
+// fileList collects the values of a flag that may be repeated.
+type fileList []string
+
+func (f *fileList) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *fileList) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
 
 func init() {
 	flag.StringVar(&flagKubeconfig, "kubeconfig", "", "kubeconfig file")
+	flag.Var(&flagFiles, "f", "YAML file or directory to load instead of using the API server; may be repeated")
+	flag.StringVar(&flagNamespace, "n", "default", "namespace of the pod")
 	flag.BoolVar(&flagVerbose, "v", false, "verbose output")
+	flag.BoolVar(&flagExplain, "explain", false, "say which constraints each rejected device failed; implies -v")
 	flag.Usage = usage
 }
 
 func usage() {
-	fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [ -v ] -kubeconfig <file> <pod-name>\n", os.Args[0])
+	fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [ -v ] [ -explain ] [ -n <namespace> ] -kubeconfig <file> <pod-name>\n", os.Args[0])
+	fmt.Fprintf(flag.CommandLine.Output(), "       %s [ -v ] [ -explain ] [ -n <namespace> ] -f <file-or-dir> [ -f ... ] <pod-name>\n", os.Args[0])
 	flag.PrintDefaults()
 }
 
@@ -31,6 +56,96 @@ func main() {
 		os.Exit(1)
 	}
 
-	podName := args[0]
-	fmt.Fprintf(flag.CommandLine.Output(), "scheduling pod %q not implemented yet\n", podName)
+	flagPodName = args[0]
+
+	if len(flagFiles) > 0 {
+		scheduleOffline()
+		return
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", flagKubeconfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading kubeconfig: %v\n", err)
+		os.Exit(1)
+	}
+
+	cs, err := client.NewForConfig(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error creating client: %v\n", err)
+		os.Exit(1)
+	}
+
+	dc, err := dynamic.NewForConfig(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error creating dynamic client: %v\n", err)
+		os.Exit(1)
+	}
+
+	s := &apiServer{client: cs, dynamic: dc, explain: flagExplain}
+	result, err := s.schedulePod(context.Background(), flagNamespace, flagPodName)
+	if result != nil {
+		printResult(result)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error scheduling pod %s/%s: %v\n", flagNamespace, flagPodName, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("pod %s/%s bound to node %q\n", flagNamespace, flagPodName, result.nodeName)
+}
+
+// scheduleOffline schedules the pod using only the objects in the files, and
+// prints the results without writing anything.
+func scheduleOffline() {
+	cluster, err := loadFiles(flagFiles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading files: %v\n", err)
+		os.Exit(1)
+	}
+	cluster.explain = flagExplain
+
+	result, err := scheduleFromFiles(cluster, flagNamespace, flagPodName)
+	if result != nil {
+		printResult(result)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error scheduling pod %s/%s: %v\n", flagNamespace, flagPodName, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("pod %s/%s would be bound to node %q\n", flagNamespace, flagPodName, result.nodeName)
+}
+
+func printResult(result *podResult) {
+	if len(result.nodeResults) > 0 {
+		fmt.Println("NODE RESULTS")
+		fmt.Println("------------")
+		if flagVerbose || flagExplain {
+			b, _ := yaml.Marshal(result.nodeResults)
+			fmt.Println(string(b))
+		} else {
+			for _, nr := range result.nodeResults {
+				fmt.Println(nr.Summary())
+			}
+			fmt.Println()
+		}
+	}
+
+	if len(result.allocations) == 0 {
+		return
+	}
+
+	var names []string
+	for name := range result.allocations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("ALLOCATIONS")
+	fmt.Println("-----------")
+	for _, name := range names {
+		b, _ := yaml.Marshal(map[string]any{name: result.allocations[name]})
+		fmt.Print(string(b))
+	}
+	fmt.Println()
 }
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"github.com/johnbelamaric/k8srm-prototype/pkg/schedule"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// podClaim is a DeviceClaim needed by a pod.
+type podClaim struct {
+	claim api.DeviceClaim
+
+	// create is true if the claim was generated from a template or an
+	// embedded claim, and does not exist yet.
+	create bool
+}
+
+// clusterState contains the objects the scheduler needs, other than the pod
+// and its claims.
+type clusterState struct {
+	drivers []api.DeviceDriver
+	classes []api.DeviceClass
+	pools   []api.DevicePool
+
+	// claims are all of the existing DeviceClaims, which are used to
+	// find the devices that are already allocated.
+	claims []api.DeviceClaim
+
+	// explain turns on explain mode in the scheduler, so that the node
+	// results say which constraints each rejected device failed.
+	explain bool
+}
+
+// podResult is the outcome of scheduling a pod.
+type podResult struct {
+	nodeName string
+
+	// allocations holds the new allocations for each claim, by claim
+	// name. Claims that were already allocated do not appear.
+	allocations map[string][]api.DeviceAllocation
+
+	// nodeResults are the results of evaluating each node. It is empty if
+	// all the claims were already allocated.
+	nodeResults []schedule.NodeResult
+}
+
+// claimNameFor returns the name of the claim generated for the pod from a
+// template or an embedded claim.
+func claimNameFor(pod *api.Pod, pdc *api.PodDeviceClaim) string {
+	return pod.Name + "-" + pdc.Name
+}
+
+// resolvePodClaims returns the DeviceClaims needed by the pod. The getClaim
+// function looks up a DeviceClaim by name in the pod's namespace, and must
+// return a NotFound error if it does not exist.
+//
+// Claims named in the pod are used as is. For templates and embedded claims,
+// a claim named after the pod and the entry is generated, unless one owned by
+// the pod already exists from an earlier attempt.
+func resolvePodClaims(pod *api.Pod, getClaim func(name string) (*api.DeviceClaim, error)) ([]podClaim, error) {
+	var result []podClaim
+	seen := make(map[string]bool)
+	for i := range pod.Spec.DeviceClaims {
+		pdc := &pod.Spec.DeviceClaims[i]
+
+		var pc podClaim
+		switch {
+		case pdc.ClaimName != nil:
+			claim, err := getClaim(*pdc.ClaimName)
+			if err != nil {
+				return nil, fmt.Errorf("device claim %q: %w", pdc.Name, err)
+			}
+			pc.claim = *claim
+
+		case pdc.ClaimTemplateName != nil || pdc.Claim != nil:
+			existing, err := getClaim(claimNameFor(pod, pdc))
+			if err == nil {
+				if !metav1.IsControlledBy(existing, pod) {
+					return nil, fmt.Errorf("device claim %q: claim %q already exists and is not owned by the pod", pdc.Name, existing.Name)
+				}
+				pc.claim = *existing
+				break
+			}
+			if !errors.IsNotFound(err) {
+				return nil, fmt.Errorf("device claim %q: %w", pdc.Name, err)
+			}
+
+			pc.claim, err = newPodClaim(pod, pdc, getClaim)
+			if err != nil {
+				return nil, fmt.Errorf("device claim %q: %w", pdc.Name, err)
+			}
+			pc.create = true
+
+		default:
+			return nil, fmt.Errorf("device claim %q: one of claimName, claimTemplateName or claim must be set", pdc.Name)
+		}
+
+		// Two entries may refer to the same shared claim.
+		if seen[pc.claim.Name] {
+			continue
+		}
+		seen[pc.claim.Name] = true
+
+		result = append(result, pc)
+	}
+
+	return result, nil
+}
+
+// newPodClaim generates the DeviceClaim for a template or an embedded claim.
+func newPodClaim(pod *api.Pod, pdc *api.PodDeviceClaim, getClaim func(name string) (*api.DeviceClaim, error)) (api.DeviceClaim, error) {
+	claim := api.DeviceClaim{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: api.DevMgmtAPIVersion,
+			Kind:       "DeviceClaim",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      claimNameFor(pod, pdc),
+			Namespace: pod.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(pod, podGVK),
+			},
+		},
+	}
+
+	if pdc.ClaimTemplateName != nil {
+		template, err := getClaim(*pdc.ClaimTemplateName)
+		if err != nil {
+			return claim, fmt.Errorf("template: %w", err)
+		}
+		claim.Labels = template.Labels
+		claim.Annotations = template.Annotations
+		claim.Spec = *template.Spec.DeepCopy()
+		return claim, nil
+	}
+
+	embedded := pdc.Claim.DeepCopy()
+	claim.Labels = embedded.Labels
+	claim.Annotations = embedded.Annotations
+	claim.Spec = api.DeviceClaimSpec{
+		MatchAttributes:    embedded.MatchAttributes,
+		DistinctAttributes: embedded.DistinctAttributes,
+		Claims:             embedded.Claims,
+	}
+
+	return claim, nil
+}
+
+// selectNodeForPod chooses the node for the pod and allocates devices for any
+// of its claims that are not yet allocated. Claims that are already allocated,
+// for example because they are shared with another pod, pin the pod to the
+// node of their devices.
+func selectNodeForPod(pod *api.Pod, claims []podClaim, cs clusterState) (*podResult, error) {
+	if err := checkClasses(claims, cs.classes); err != nil {
+		return nil, err
+	}
+
+	poolNodes := make(map[string]string)
+	for _, p := range cs.pools {
+		if p.Spec.NodeName != nil {
+			poolNodes[p.Name] = *p.Spec.NodeName
+		}
+	}
+
+	podClaimNames := make(map[string]bool)
+	for _, pc := range claims {
+		podClaimNames[pc.claim.Name] = true
+	}
+
+	// Devices allocated to other claims are not available. The claims of
+	// the pod are taken from the claims passed in, which may be newer.
+	var existing []api.DeviceAllocation
+	for _, c := range cs.claims {
+		if c.Namespace == pod.Namespace && podClaimNames[c.Name] {
+			continue
+		}
+		existing = append(existing, c.Status.Allocations...)
+	}
+
+	var pinnedNode string
+	var unallocated []api.DeviceClaim
+	for _, pc := range claims {
+		if len(pc.claim.Status.Allocations) == 0 {
+			unallocated = append(unallocated, pc.claim)
+			continue
+		}
+
+		// The devices of claims that are already allocated are not
+		// available to the other claims of the pod either.
+		existing = append(existing, pc.claim.Status.Allocations...)
+
+		for _, a := range pc.claim.Status.Allocations {
+			node, ok := poolNodes[a.DevicePoolName]
+			if !ok {
+				return nil, fmt.Errorf("claim %q is allocated from pool %q, which is not associated with a node", pc.claim.Name, a.DevicePoolName)
+			}
+			if pinnedNode != "" && node != pinnedNode {
+				return nil, fmt.Errorf("claim %q is allocated on node %q, but other claims of the pod are allocated on node %q", pc.claim.Name, node, pinnedNode)
+			}
+			pinnedNode = node
+		}
+	}
+
+	result := &podResult{
+		nodeName:    pinnedNode,
+		allocations: make(map[string][]api.DeviceAllocation),
+	}
+
+	if len(unallocated) == 0 {
+		if pinnedNode == "" {
+			return nil, fmt.Errorf("pod has no device claims")
+		}
+		return result, nil
+	}
+
+	pools := cs.pools
+	if pinnedNode != "" {
+		pools = nil
+		for _, p := range cs.pools {
+			if poolNodes[p.Name] == pinnedNode {
+				pools = append(pools, p)
+			}
+		}
+	}
+
+	_, result.nodeResults = schedule.SelectNode(unallocated, nil, schedule.Cluster{
+		Drivers:     cs.drivers,
+		Classes:     cs.classes,
+		Pools:       pools,
+		Allocations: existing,
+		Explain:     cs.explain,
+	})
+
+	best := schedule.BestNode(result.nodeResults)
+	if best == nil {
+		return result, fmt.Errorf("no node can satisfy the device claims of pod %s/%s", pod.Namespace, pod.Name)
+	}
+
+	result.nodeName = best.NodeName
+	for i := range best.DeviceClaimResults {
+		dcr := &best.DeviceClaimResults[i]
+		result.allocations[dcr.ClaimName] = dcr.Allocations()
+	}
+
+	return result, nil
+}
+
+// checkClasses makes sure that every DeviceClass named by the claims exists.
+func checkClasses(claims []podClaim, classes []api.DeviceClass) error {
+	names := make(map[string]bool)
+	for _, c := range classes {
+		names[c.Name] = true
+	}
+
+	check := func(claimName string, detail *api.DeviceClaimDetail) error {
+		if detail.DeviceClass != nil && !names[*detail.DeviceClass] {
+			return fmt.Errorf("claim %q: device class %q not found", claimName, *detail.DeviceClass)
+		}
+		return nil
+	}
+
+	for _, pc := range claims {
+		for i := range pc.claim.Spec.Claims {
+			ci := &pc.claim.Spec.Claims[i]
+			if err := check(pc.claim.Name, &ci.DeviceClaimDetail); err != nil {
+				return err
+			}
+			for j := range ci.OneOf {
+				if err := check(pc.claim.Name, &ci.OneOf[j]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"github.com/johnbelamaric/k8srm-prototype/pkg/gen"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSelectNodeForPodAllocatedClaim(t *testing.T) {
+	pod := &api.Pod{ObjectMeta: metav1.ObjectMeta{Name: "mypod", Namespace: "default"}}
+
+	newClaim := func(name string, allocations ...api.DeviceAllocation) api.DeviceClaim {
+		return api.DeviceClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: api.DeviceClaimSpec{
+				Claims: []api.DeviceClaimInstance{{}},
+			},
+			Status: api.DeviceClaimStatus{Allocations: allocations},
+		}
+	}
+
+	// The shared claim already holds the first device on the second node,
+	// so the pod is pinned there, and the other claim of the pod must get
+	// a different device.
+	shared := newClaim("shared", api.DeviceAllocation{DevicePoolName: "foozer-1000-small-01-foozer", DeviceName: "dev-00"})
+	other := newClaim("other")
+
+	result, err := selectNodeForPod(pod, []podClaim{{claim: shared}, {claim: other, create: true}}, clusterState{
+		pools:  gen.Gen("foozer-1000-small", 2),
+		claims: []api.DeviceClaim{shared},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "foozer-1000-small-01", result.nodeName)
+	require.NotContains(t, result.allocations, "shared")
+	require.Equal(t, []api.DeviceAllocation{{DevicePoolName: "foozer-1000-small-01-foozer", DeviceName: "dev-01"}}, result.allocations["other"])
+}
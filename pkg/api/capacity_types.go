@@ -6,13 +6,21 @@ import (
 )
 
 const (
-	DevMgmtAPIVersion = "devmgmtproto.k8s.io/v1alpha1"
+	DevMgmtAPIVersion = GroupName + "/" + Version
 )
 
 // DevicePool represents a collection of devices managed by a given driver. How
 // devices are divided into pools is driver-specific, but typically the
 // expectation would a be a pool per identical collection of devices, per node.
 // It is fine to have more than one pool for a given node, for the same driver.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.spec.nodeName`
+// +kubebuilder:printcolumn:name="Driver",type=string,JSONPath=`.spec.driver`
+// +kubebuilder:printcolumn:name="Available",type=integer,JSONPath=`.status.availableDevices`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 type DevicePool struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -21,6 +29,16 @@ type DevicePool struct {
 	Status DevicePoolStatus `json:"status,omitempty"`
 }
 
+// DevicePoolList is a list of DevicePools.
+//
+// +kubebuilder:object:root=true
+type DevicePoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []DevicePool `json:"items"`
+}
+
 // DevicePoolSpec identifies the driver and contains the data for the pool
 // prior to any allocations.
 // NOTE: It's not clear that spec/status is the right model for this data.
@@ -53,6 +71,25 @@ type DevicePoolSpec struct {
 	Devices []Device `json:"devices,omitempty"`
 }
 
+// DeviceAttributes returns the effective attributes of a device in the pool.
+// These are the pool attributes followed by the device attributes, except
+// that a pool attribute is left out if the device has one with the same name.
+func (s *DevicePoolSpec) DeviceAttributes(device *Device) []Attribute {
+	names := make(map[string]bool, len(device.Attributes))
+	for _, a := range device.Attributes {
+		names[a.Name] = true
+	}
+
+	attrs := make([]Attribute, 0, len(s.Attributes)+len(device.Attributes))
+	for _, a := range s.Attributes {
+		if !names[a.Name] {
+			attrs = append(attrs, a)
+		}
+	}
+
+	return append(attrs, device.Attributes...)
+}
+
 // DevicePoolStatus contains the state of the pool as last reported by the
 // driver. Note that this will not include the allocations that have been made
 // by the scheduler but not yet seen by the driver. Thus, it is NOT sufficient
@@ -146,6 +183,7 @@ func (a Attribute) EqualValue(b Attribute) bool {
 	return false
 }
 
-// SemVer represents a semantic version value. In this prototype it is just a
-// string.
+// SemVer represents a semantic version value, as described at
+// https://semver.org; e.g., 4.2.1-gen3. It is stored as a string, but is
+// compared as a version in constraints.
 type SemVer string
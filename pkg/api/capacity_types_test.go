@@ -0,0 +1,41 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceAttributes(t *testing.T) {
+	spec := DevicePoolSpec{
+		Attributes: []Attribute{
+			{Name: "vendor", StringValue: ptr("example.com")},
+			{Name: "model", StringValue: ptr("foozer-1000")},
+			{Name: "firmwareVersion", SemVerValue: ptr(SemVer("1.8.2"))},
+		},
+	}
+
+	t.Run("no device attributes", func(t *testing.T) {
+		require.Equal(t, spec.Attributes, spec.DeviceAttributes(&Device{Name: "dev-00"}))
+	})
+
+	t.Run("device attributes override the pool", func(t *testing.T) {
+		device := &Device{
+			Name: "dev-00",
+			Attributes: []Attribute{
+				{Name: "numa", IntValue: ptr(1)},
+				{Name: "firmwareVersion", SemVerValue: ptr(SemVer("1.9.0"))},
+			},
+		}
+
+		require.Equal(t, []Attribute{
+			{Name: "vendor", StringValue: ptr("example.com")},
+			{Name: "model", StringValue: ptr("foozer-1000")},
+			{Name: "numa", IntValue: ptr(1)},
+			{Name: "firmwareVersion", SemVerValue: ptr(SemVer("1.9.0"))},
+		}, spec.DeviceAttributes(device))
+
+		// The pool is not modified.
+		require.Equal(t, SemVer("1.8.2"), *spec.Attributes[2].SemVerValue)
+	})
+}
@@ -6,10 +6,22 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+const (
+	// CountResource is the name of the request that all drivers support,
+	// which is the number of devices to allocate.
+	CountResource = "count"
+)
+
 // DeviceClass is a vendor or admin-provided resource that contains
 // contraint and configuration information. Essentially, it is a re-usable
 // collection of predefined data that device claims may use.
 // Cluster scoped.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Device Type",type=string,JSONPath=`.spec.deviceType`
+// +kubebuilder:printcolumn:name="Driver",type=string,JSONPath=`.spec.driver`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 type DeviceClass struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -17,13 +29,23 @@ type DeviceClass struct {
 	Spec DeviceClassSpec `json:"spec,omitempty"`
 }
 
+// DeviceClassList is a list of DeviceClasses.
+//
+// +kubebuilder:object:root=true
+type DeviceClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []DeviceClass `json:"items"`
+}
+
 // DeviceClassSpec provides the details of the DeviceClass.
 type DeviceClassSpec struct {
 	// Driver specifies the driver that should handle this class of devices.
 	// When a DeviceClaim uses this class, only devices published by the
 	// specified driver will be considered.
 	// +required
-	Driver string `json:driver,omitempty`
+	Driver string `json:"driver,omitempty"`
 
 	// DeviceType is a driver-independent classification of the device.  In
 	// claims, this may be used instead of specifying the class
@@ -35,7 +57,7 @@ type DeviceClassSpec struct {
 	// device to indicate device functions.
 	//
 	// +required
-	DeviceType string `json:deviceType,omitempty`
+	DeviceType string `json:"deviceType,omitempty"`
 
 	// Constraints is a CEL expression that operates on device attributes,
 	// and must evaluate to true for a device to be considered. It will be
@@ -51,6 +73,9 @@ type DeviceClassSpec struct {
 	// modes and any resource allocations. Access to these classes must be
 	// controlled via ResourceQuota. Default is false.
 	//
+	// DevicePrivilegedClaim provides the same access without the need for
+	// a dedicated class.
+	//
 	// +optional
 	AdminAccess *bool `json:"adminAccess,omitempty"`
 
@@ -63,6 +88,11 @@ type DeviceClassSpec struct {
 
 // DeviceClaim is used to specify a request for a set of devices.
 // Namespace scoped.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Pods",type=string,JSONPath=`.status.podNames`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 type DeviceClaim struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -71,21 +101,71 @@ type DeviceClaim struct {
 	Status DeviceClaimStatus `json:"status,omitempty"`
 }
 
+// DeviceClaimList is a list of DeviceClaims.
+//
+// +kubebuilder:object:root=true
+type DeviceClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []DeviceClaim `json:"items"`
+}
+
+// DevicePrivilegedClaim is used to request administrative access to a set of
+// devices, for example by monitoring or other device management services.
+// Unlike a DeviceClaim, it may be satisfied by devices that are already
+// allocated to other claims, and it does not consume any of their capacity.
+// Since this bypasses the usual sharing rules, it is a separate resource so
+// that access to it can be controlled independently of DeviceClaim, via RBAC
+// and ResourceQuota.
+// Namespace scoped.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Pods",type=string,JSONPath=`.status.podNames`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type DevicePrivilegedClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DeviceClaimSpec   `json:"spec,omitempty"`
+	Status DeviceClaimStatus `json:"status,omitempty"`
+}
+
+// DevicePrivilegedClaimList is a list of DevicePrivilegedClaims.
+//
+// +kubebuilder:object:root=true
+type DevicePrivilegedClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []DevicePrivilegedClaim `json:"items"`
+}
+
 // DeviceClaimSpec details the requirements that devices chosen
 // to satisfy this claim must meet.
 type DeviceClaimSpec struct {
 	// MatchAttributes allows specifying a constraint that will apply
 	// across all of the claims. For example, if you specified "numa", then
 	// this overall claim could only be successfully fulfilled if all of
-	// the included claims could be fulfilled by pools with the same "numa"
-	// attribute value. If we simply set matchAttributes in each claim
-	// separately, then they could be consistent within claims, but
+	// the included claims could be fulfilled by devices with the same
+	// "numa" attribute value. If we simply set matchAttributes in each
+	// claim separately, then they could be consistent within claims, but
 	// inconsistent across claims. Therefore, we need this additional
-	// constraint.
+	// constraint. Devices without the attributes cannot be used.
 	//
 	// +optional
 	MatchAttributes []string `json:"matchAttributes,omitempty"`
 
+	// DistinctAttributes is the opposite of MatchAttributes: every device
+	// chosen for any of the included claims must have a different value
+	// for each of these attributes. For example, if you specified "pf",
+	// then no two of the devices could come from the same physical
+	// function. Devices without the attributes cannot be used.
+	//
+	// +optional
+	DistinctAttributes []string `json:"distinctAttributes,omitempty"`
+
 	// Claims contains the actual claim details, arranged into groups
 	// containing claims which must all be satsified, or for which only
 	// one needs to be satisfied.
@@ -104,7 +184,9 @@ type DeviceClaimInstance struct {
 	DeviceClaimDetail `json:",inline"`
 
 	// OneOf contains a list of claims, only one of which must be satisfied.
-	// Claims are listed in order of priority.
+	// Claims are listed in order of priority. On each node, the first
+	// claim that can be satisfied is used, and nodes that can satisfy an
+	// earlier claim are preferred.
 	//
 	// +optional
 	OneOf []DeviceClaimDetail `json:"oneOf,omitempty"`
@@ -131,6 +213,22 @@ type DeviceClaimDetail struct {
 	// In order for a device to be considered, this CEL expression and the
 	// Constraints expression from the DeviceClass must both be true.
 	//
+	// The `device` variable holds the attributes of the device, along with
+	// those of its pool that the device does not override, and the name
+	// of its driver as `device.driver`. The `pool` variable holds the
+	// `name`, `nodeName` and `driver` of the pool.
+	//
+	// Both variables also have `capacity` and `resources` maps, from each
+	// resource name to its total capacity and to the amount that remains
+	// after existing allocations, respectively. For example,
+	// `device.resources.memory >= quantity('40Gi')`.
+	//
+	// Besides the standard CEL functions, `device.hasAttribute(name)` and
+	// `device.attributeOr(name, default)` handle attributes that only
+	// some devices have, `matchesRegex` and `matchesGlob` match the string
+	// form of a value, `csv()` and `csvContains(item)` treat a string as
+	// a comma-separated set, and a quantity may be compared to an int.
+	//
 	// +optional
 	Constraints *string `json:"constraints,omitempty"`
 
@@ -166,6 +264,15 @@ type DeviceClaimDetail struct {
 	// +optional
 	MatchAttributes []string `json:"matchAttributes,omitempty"`
 
+	// DistinctAttributes requires each of the chosen devices to have a
+	// different value for each of these attributes. For example, "4 VFs
+	// from 4 different PFs" is a count of 4 with distinctAttributes of
+	// "pf". Devices without the attributes cannot be chosen, since
+	// nothing says that they are distinct from the others.
+	//
+	// +optional
+	DistinctAttributes []string `json:"distinctAttributes,omitempty"`
+
 	// Configs contains references to arbitrary vendor device configuration
 	// objects that will be attached to the device allocation.
 	// +optional
@@ -291,7 +398,7 @@ type ResourceAllocation struct {
 
 	// Amount is the amount of resource allocated for this claim.
 	// +required
-	Allocation resource.Quantity `json:"allocation,inline"`
+	Allocation resource.Quantity `json:"allocation"`
 
 	// If we ever need to support intra-device topology (for example,
 	// if standard node compute becomes a device), then we may also
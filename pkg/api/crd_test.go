@@ -0,0 +1,73 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"sigs.k8s.io/yaml"
+)
+
+// TestCRDs checks that the generated CRDs in config/crd are up to date with
+// the registered kinds, and that their schemas are structural so that they
+// will be accepted by a real API server.
+func TestCRDs(t *testing.T) {
+	files, err := filepath.Glob("../../config/crd/*.yaml")
+	require.NoError(t, err)
+
+	crds := make(map[string]apiextensionsv1.CustomResourceDefinition)
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		require.NoError(t, err)
+
+		var crd apiextensionsv1.CustomResourceDefinition
+		require.NoError(t, yaml.Unmarshal(b, &crd), f)
+		require.Equal(t, GroupName, crd.Spec.Group, f)
+		crds[crd.Spec.Names.Kind] = crd
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+
+	testCases := map[string]struct {
+		scope     apiextensionsv1.ResourceScope
+		hasStatus bool
+	}{
+		"DeviceDriver":          {scope: apiextensionsv1.ClusterScoped},
+		"DeviceClass":           {scope: apiextensionsv1.ClusterScoped},
+		"DeviceClaim":           {scope: apiextensionsv1.NamespaceScoped, hasStatus: true},
+		"DevicePrivilegedClaim": {scope: apiextensionsv1.NamespaceScoped, hasStatus: true},
+		"DevicePool":            {scope: apiextensionsv1.ClusterScoped, hasStatus: true},
+	}
+
+	require.Len(t, crds, len(testCases))
+
+	for kind, tc := range testCases {
+		t.Run(kind, func(t *testing.T) {
+			crd, ok := crds[kind]
+			require.True(t, ok, "no CRD for %s", kind)
+			require.Equal(t, tc.scope, crd.Spec.Scope)
+			require.Len(t, crd.Spec.Versions, 1)
+
+			v := crd.Spec.Versions[0]
+			require.Equal(t, Version, v.Name)
+			require.True(t, scheme.Recognizes(SchemeGroupVersion.WithKind(kind)))
+			require.Equal(t, tc.hasStatus, v.Subresources != nil && v.Subresources.Status != nil)
+
+			require.NotNil(t, v.Schema)
+			var internal apiextensions.JSONSchemaProps
+			require.NoError(t, apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(v.Schema.OpenAPIV3Schema, &internal, nil))
+			s, err := structuralschema.NewStructural(&internal)
+			require.NoError(t, err)
+			require.Empty(t, structuralschema.ValidateStructural(field.NewPath("openAPIV3Schema"), s))
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func addDefaultingFuncs(scheme *runtime.Scheme) error {
+	return RegisterDefaults(scheme)
+}
+
+// RegisterDefaults adds the defaulting functions for each of the top-level
+// types to the scheme, so that they are applied whenever objects are decoded.
+func RegisterDefaults(scheme *runtime.Scheme) error {
+	scheme.AddTypeDefaultingFunc(&DeviceClass{}, func(obj interface{}) { SetDefaults_DeviceClass(obj.(*DeviceClass)) })
+	scheme.AddTypeDefaultingFunc(&DeviceClaim{}, func(obj interface{}) { SetDefaults_DeviceClaim(obj.(*DeviceClaim)) })
+	scheme.AddTypeDefaultingFunc(&DevicePrivilegedClaim{}, func(obj interface{}) { SetDefaults_DevicePrivilegedClaim(obj.(*DevicePrivilegedClaim)) })
+	scheme.AddTypeDefaultingFunc(&DevicePool{}, func(obj interface{}) { SetDefaults_DevicePool(obj.(*DevicePool)) })
+	return nil
+}
+
+// SetDefaults_DeviceClass sets the defaults for the class spec.
+func SetDefaults_DeviceClass(in *DeviceClass) {
+	SetDefaults_DeviceClassSpec(&in.Spec)
+}
+
+// SetDefaults_DeviceClaim sets the defaults for each claim detail.
+func SetDefaults_DeviceClaim(in *DeviceClaim) {
+	SetDefaults_DeviceClaimSpec(&in.Spec)
+}
+
+// SetDefaults_DevicePrivilegedClaim sets the defaults for each claim detail.
+func SetDefaults_DevicePrivilegedClaim(in *DevicePrivilegedClaim) {
+	SetDefaults_DeviceClaimSpec(&in.Spec)
+}
+
+// SetDefaults_DevicePool sets the defaults for the resources of the pool and
+// of each of its devices.
+func SetDefaults_DevicePool(in *DevicePool) {
+	for i := range in.Spec.Resources {
+		SetDefaults_ResourceCapacity(&in.Spec.Resources[i])
+	}
+
+	for i := range in.Spec.Devices {
+		d := &in.Spec.Devices[i]
+		for j := range d.Resources {
+			SetDefaults_ResourceCapacity(&d.Resources[j])
+		}
+	}
+}
+
+// SetDefaults_DeviceClaimSpec sets the defaults for each claim detail, whether
+// inline or in OneOf.
+func SetDefaults_DeviceClaimSpec(obj *DeviceClaimSpec) {
+	for i := range obj.Claims {
+		ci := &obj.Claims[i]
+
+		// Only one of the inline details and OneOf may be used, so
+		// the inline details must not be defaulted when OneOf is in
+		// use; that would make it look like both are set.
+		if len(ci.OneOf) == 0 {
+			SetDefaults_DeviceClaimDetail(&ci.DeviceClaimDetail)
+			continue
+		}
+
+		for j := range ci.OneOf {
+			SetDefaults_DeviceClaimDetail(&ci.OneOf[j])
+		}
+	}
+}
+
+// SetDefaults_DeviceClassSpec sets AdminAccess to false if it is not set.
+func SetDefaults_DeviceClassSpec(obj *DeviceClassSpec) {
+	if obj.AdminAccess == nil {
+		adminAccess := false
+		obj.AdminAccess = &adminAccess
+	}
+}
+
+// SetDefaults_DeviceClaimDetail sets the count request to one if it is not
+// set. Other requests are left alone.
+func SetDefaults_DeviceClaimDetail(obj *DeviceClaimDetail) {
+	if _, ok := obj.Requests[CountResource]; ok {
+		return
+	}
+
+	if obj.Requests == nil {
+		obj.Requests = make(map[string]resource.Quantity)
+	}
+
+	obj.Requests[CountResource] = resource.MustParse("1")
+}
+
+// SetDefaults_ResourceCapacity sets the BlockSize to one if it is not set.
+func SetDefaults_ResourceCapacity(obj *ResourceCapacity) {
+	if obj.BlockSize == nil {
+		blockSize := resource.MustParse("1")
+		obj.BlockSize = &blockSize
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func ptr[T any](val T) *T {
+	var v T = val
+	return &v
+}
+
+func TestDefaults(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+
+	t.Run("DeviceClass", func(t *testing.T) {
+		class := &DeviceClass{}
+		scheme.Default(class)
+		require.Equal(t, ptr(false), class.Spec.AdminAccess)
+
+		class = &DeviceClass{Spec: DeviceClassSpec{AdminAccess: ptr(true)}}
+		scheme.Default(class)
+		require.Equal(t, ptr(true), class.Spec.AdminAccess)
+	})
+
+	t.Run("DeviceClaim", func(t *testing.T) {
+		claim := &DeviceClaim{
+			Spec: DeviceClaimSpec{
+				Claims: []DeviceClaimInstance{
+					{
+						DeviceClaimDetail: DeviceClaimDetail{
+							DeviceClass: ptr("example.com-foozer-set"),
+						},
+					},
+					{
+						DeviceClaimDetail: DeviceClaimDetail{
+							DeviceClass: ptr("nvidia-h100-mps"),
+							Requests: map[string]resource.Quantity{
+								"memory": resource.MustParse("8Gi"),
+							},
+						},
+					},
+					{
+						DeviceClaimDetail: DeviceClaimDetail{
+							DeviceClass: ptr("nvidia-l4"),
+							Requests: map[string]resource.Quantity{
+								CountResource: resource.MustParse("2"),
+							},
+						},
+					},
+					{
+						OneOf: []DeviceClaimDetail{
+							{DeviceClass: ptr("nvidia-a100")},
+							{DeviceClass: ptr("nvidia-h100")},
+						},
+					},
+				},
+			},
+		}
+		scheme.Default(claim)
+
+		one := resource.MustParse("1")
+		require.Equal(t, map[string]resource.Quantity{CountResource: one}, claim.Spec.Claims[0].Requests)
+		require.Equal(t, map[string]resource.Quantity{CountResource: one, "memory": resource.MustParse("8Gi")}, claim.Spec.Claims[1].Requests)
+		require.Equal(t, map[string]resource.Quantity{CountResource: resource.MustParse("2")}, claim.Spec.Claims[2].Requests)
+		require.Nil(t, claim.Spec.Claims[3].Requests)
+		require.Equal(t, map[string]resource.Quantity{CountResource: one}, claim.Spec.Claims[3].OneOf[0].Requests)
+		require.Equal(t, map[string]resource.Quantity{CountResource: one}, claim.Spec.Claims[3].OneOf[1].Requests)
+	})
+
+	t.Run("DevicePool", func(t *testing.T) {
+		pool := &DevicePool{
+			Spec: DevicePoolSpec{
+				Resources: []ResourceCapacity{
+					{Name: "memory", Capacity: resource.MustParse("80Gi")},
+				},
+				Devices: []Device{
+					{
+						Name: "dev-00",
+						Resources: []ResourceCapacity{
+							{Name: "memory", Capacity: resource.MustParse("40Gi"), BlockSize: ptr(resource.MustParse("4Ki"))},
+							{Name: "cores", Capacity: resource.MustParse("8")},
+						},
+					},
+				},
+			},
+		}
+		scheme.Default(pool)
+
+		require.Equal(t, ptr(resource.MustParse("1")), pool.Spec.Resources[0].BlockSize)
+		require.Equal(t, ptr(resource.MustParse("4Ki")), pool.Spec.Devices[0].Resources[0].BlockSize)
+		require.Equal(t, ptr(resource.MustParse("1")), pool.Spec.Devices[0].Resources[1].BlockSize)
+	})
+}
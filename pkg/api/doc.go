@@ -0,0 +1,7 @@
+// Package api contains the types for the devmgmtproto.k8s.io/v1alpha1 API
+// group.
+//
+// +kubebuilder:object:generate=true
+// +groupName=devmgmtproto.k8s.io
+// +versionName=v1alpha1
+package api
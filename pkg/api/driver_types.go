@@ -0,0 +1,116 @@
+package api
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeviceDriver is published by each driver when it registers with the control
+// plane. It advertises the driver-independent device types that the driver can
+// provide, so that claims may request a device type rather than a specific
+// class or driver.
+// Cluster scoped.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Device Types",type=string,JSONPath=`.spec.deviceTypes`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type DeviceDriver struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DeviceDriverSpec `json:"spec,omitempty"`
+}
+
+// DeviceDriverList is a list of DeviceDrivers.
+//
+// +kubebuilder:object:root=true
+type DeviceDriverList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []DeviceDriver `json:"items"`
+}
+
+// DeviceDriverSpec contains the details of what the driver supports.
+type DeviceDriverSpec struct {
+	// DeviceTypes is the list of device types, such as "gpu" or
+	// "sriov-nic", that the devices published by this driver can
+	// satisfy.
+	//
+	// +required
+	DeviceTypes []string `json:"deviceTypes,omitempty"`
+
+	// Attributes is the schema of the attributes that the driver publishes
+	// for its pools and devices. When it is set, the constraints in
+	// classes for this driver are type-checked against it when the class
+	// is created, and may only refer to these attributes.
+	//
+	// +optional
+	Attributes []AttributeSchema `json:"attributes,omitempty"`
+}
+
+// AttributeSchema describes an attribute published by a driver.
+type AttributeSchema struct {
+	// Name is the name of the attribute.
+	// +required
+	Name string `json:"name"`
+
+	// Type is the type of the attribute value.
+	// +required
+	Type AttributeType `json:"type"`
+}
+
+// AttributeType is the type of an attribute value. Each type corresponds to
+// one of the value fields of an Attribute.
+//
+// +kubebuilder:validation:Enum=string;int;quantity;semver
+type AttributeType string
+
+const (
+	AttributeTypeString   AttributeType = "string"
+	AttributeTypeInt      AttributeType = "int"
+	AttributeTypeQuantity AttributeType = "quantity"
+	AttributeTypeSemVer   AttributeType = "semver"
+)
+
+// SupportsDeviceType returns true if the driver publishes devices of the
+// given type.
+func (d *DeviceDriver) SupportsDeviceType(deviceType string) bool {
+	for _, t := range d.Spec.DeviceTypes {
+		if t == deviceType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResolveDeviceType returns the drivers that support the given device type,
+// along with the DeviceClasses that can serve it. A class can serve the device
+// type if it is for that device type, and either does not name a driver or
+// names one of the returned drivers.
+func ResolveDeviceType(deviceType string, drivers []DeviceDriver, classes []DeviceClass) ([]DeviceDriver, []DeviceClass) {
+	var resultDrivers []DeviceDriver
+	driverNames := make(map[string]bool)
+	for _, d := range drivers {
+		if d.SupportsDeviceType(deviceType) {
+			resultDrivers = append(resultDrivers, d)
+			driverNames[d.Name] = true
+		}
+	}
+
+	var resultClasses []DeviceClass
+	for _, c := range classes {
+		if c.Spec.DeviceType != deviceType {
+			continue
+		}
+
+		if c.Spec.Driver != "" && !driverNames[c.Spec.Driver] {
+			continue
+		}
+
+		resultClasses = append(resultClasses, c)
+	}
+
+	return resultDrivers, resultClasses
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResolveDeviceType(t *testing.T) {
+	driver := func(name string, deviceTypes ...string) DeviceDriver {
+		return DeviceDriver{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       DeviceDriverSpec{DeviceTypes: deviceTypes},
+		}
+	}
+	class := func(name, deviceType, driver string) DeviceClass {
+		return DeviceClass{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       DeviceClassSpec{DeviceType: deviceType, Driver: driver},
+		}
+	}
+
+	drivers := []DeviceDriver{
+		driver("example.com-foozer", "gpu"),
+		driver("example.com-barzer", "gpu", "sriov-nic"),
+		driver("example.com-nic", "sriov-nic"),
+	}
+	classes := []DeviceClass{
+		class("foozer", "gpu", "example.com-foozer"),
+		class("any-gpu", "gpu", ""),
+		class("barzer-nic", "sriov-nic", "example.com-barzer"),
+		// This driver does not support the type.
+		class("nic-gpu", "gpu", "example.com-nic"),
+		// This driver is not registered.
+		class("bazzer", "gpu", "example.com-bazzer"),
+	}
+
+	resolve := func(deviceType string) ([]string, []string) {
+		d, c := ResolveDeviceType(deviceType, drivers, classes)
+		var driverNames, classNames []string
+		for _, driver := range d {
+			driverNames = append(driverNames, driver.Name)
+		}
+		for _, class := range c {
+			classNames = append(classNames, class.Name)
+		}
+		return driverNames, classNames
+	}
+
+	d, c := resolve("gpu")
+	require.Equal(t, []string{"example.com-foozer", "example.com-barzer"}, d)
+	require.Equal(t, []string{"foozer", "any-gpu"}, c)
+
+	d, c = resolve("sriov-nic")
+	require.Equal(t, []string{"example.com-barzer", "example.com-nic"}, d)
+	require.Equal(t, []string{"barzer-nic"}, c)
+
+	d, c = resolve("vlan")
+	require.Empty(t, d)
+	require.Empty(t, c)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Pod is a core/v1 Pod with the proposed device claim fields added to its
+// spec. It is not served by this API group; it exists so that the scheduler
+// can read the device claims of Pods stored in the API server. It has no
+// TypeMeta, so that it is not mistaken for a kind of this group.
+type Pod struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PodSpec          `json:"spec,omitempty"`
+	Status corev1.PodStatus `json:"status,omitempty"`
+}
+
+// PodSpec is a core/v1 PodSpec with the addition of DeviceClaims. The
+// per-container references to those claims are not modeled here, since the
+// scheduler does not need them.
+type PodSpec struct {
+	corev1.PodSpec `json:",inline"`
+
+	// DeviceClaims are the device claims needed by the pod. Each must be
+	// satisfied on the node to which the pod is bound.
+	//
+	// +optional
+	DeviceClaims []PodDeviceClaim `json:"deviceClaims,omitempty"`
+}
+
+// PodDeviceClaim refers to, or embeds, a device claim needed by the pod.
+// Exactly one of ClaimName, ClaimTemplateName or Claim must be set.
+type PodDeviceClaim struct {
+	// Name is used by containers to refer to this claim.
+	// +required
+	Name string `json:"name"`
+
+	// ClaimName is the name of an existing DeviceClaim in the pod's
+	// namespace. The claim may be shared with other pods.
+	//
+	// +optional
+	ClaimName *string `json:"claimName,omitempty"`
+
+	// ClaimTemplateName is the name of a DeviceClaim in the pod's
+	// namespace that is used as a template. A new claim with the same spec
+	// is created for the pod, and is owned by it.
+	//
+	// +optional
+	ClaimTemplateName *string `json:"claimTemplateName,omitempty"`
+
+	// Claim is a claim embedded in the pod. A DeviceClaim is created from
+	// it for the pod, and is owned by it.
+	//
+	// +optional
+	Claim *EmbeddedDeviceClaim `json:"claim,omitempty"`
+}
+
+// EmbeddedDeviceClaim contains the fields of a DeviceClaim that can be set
+// when it is embedded in a pod. The fields of DeviceClaimSpec are unrolled,
+// rather than embedding the type, so that they may evolve independently.
+type EmbeddedDeviceClaim struct {
+	// Labels and annotations are copied to the DeviceClaim created
+	// from this.
+	//
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// MatchAttributes is the same as in DeviceClaimSpec.
+	//
+	// +optional
+	MatchAttributes []string `json:"matchAttributes,omitempty"`
+
+	// DistinctAttributes is the same as in DeviceClaimSpec.
+	//
+	// +optional
+	DistinctAttributes []string `json:"distinctAttributes,omitempty"`
+
+	// Claims is the same as in DeviceClaimSpec.
+	//
+	// +required
+	Claims []DeviceClaimInstance `json:"claims,omitempty"`
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+func TestDecode(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+	decoder := serializer.NewCodecFactory(scheme).UniversalDecoder(SchemeGroupVersion)
+
+	t.Run("drivers", func(t *testing.T) {
+		b, err := os.ReadFile("../../testdata/drivers.yaml")
+		require.NoError(t, err)
+
+		var names []string
+		reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(b)))
+		for {
+			doc, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+
+			obj, gvk, err := decoder.Decode(doc, nil, nil)
+			require.NoError(t, err)
+			require.Equal(t, SchemeGroupVersion.WithKind("DeviceDriver"), *gvk)
+
+			driver, ok := obj.(*DeviceDriver)
+			require.True(t, ok)
+			require.NotEmpty(t, driver.Spec.DeviceTypes)
+			names = append(names, driver.Name)
+		}
+
+		require.Equal(t, []string{"example.com-foozer", "example.com-barzer", "sriov-nic", "vlan"}, names)
+	})
+
+	t.Run("defaults are applied", func(t *testing.T) {
+		doc := []byte(`apiVersion: devmgmtproto.k8s.io/v1alpha1
+kind: DeviceClaim
+metadata:
+  name: myclaim
+  namespace: default
+spec:
+  claims:
+  - deviceClass: example.com-foozer-set
+`)
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		require.NoError(t, err)
+
+		claim, ok := obj.(*DeviceClaim)
+		require.True(t, ok)
+		count := claim.Spec.Claims[0].Requests["count"]
+		require.Equal(t, "1", count.String())
+	})
+
+	t.Run("every kind is registered", func(t *testing.T) {
+		for _, kind := range []string{"DeviceDriver", "DeviceClass", "DeviceClaim", "DevicePrivilegedClaim", "DevicePool"} {
+			require.True(t, scheme.Recognizes(SchemeGroupVersion.WithKind(kind)), kind)
+			require.True(t, scheme.Recognizes(SchemeGroupVersion.WithKind(kind+"List")), kind+"List")
+		}
+	})
+}
@@ -0,0 +1,498 @@
+// Package validation checks device management objects for errors that can be
+// detected when they are created, rather than waiting for them to be found
+// during scheduling.
+package validation
+
+import (
+	"fmt"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"github.com/johnbelamaric/k8srm-prototype/pkg/schedule"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateDeviceDriver validates a DeviceDriver.
+func ValidateDeviceDriver(driver *api.DeviceDriver) field.ErrorList {
+	allErrs := apivalidation.ValidateObjectMeta(&driver.ObjectMeta, false, apivalidation.NameIsDNSSubdomain, field.NewPath("metadata"))
+
+	fldPath := field.NewPath("spec", "deviceTypes")
+	if len(driver.Spec.DeviceTypes) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath, "at least one device type is required"))
+	}
+	allErrs = append(allErrs, validateNames(driver.Spec.DeviceTypes, fldPath)...)
+	allErrs = append(allErrs, validateAttributeSchema(driver.Spec.Attributes, field.NewPath("spec", "attributes"))...)
+
+	return allErrs
+}
+
+var reservedAttributeNames = sets.New(schedule.ReservedAttributeNames...)
+
+var attributeTypes = sets.New(
+	api.AttributeTypeString,
+	api.AttributeTypeInt,
+	api.AttributeTypeQuantity,
+	api.AttributeTypeSemVer,
+)
+
+func validateAttributeSchema(schema []api.AttributeSchema, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	names := sets.New[string]()
+	for i, a := range schema {
+		idxPath := fldPath.Index(i)
+		if a.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+		} else if names.Has(a.Name) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), a.Name))
+		} else if reservedAttributeNames.Has(a.Name) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), a.Name, "reserved for use in constraints"))
+		}
+		names.Insert(a.Name)
+
+		if !attributeTypes.Has(a.Type) {
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("type"), a.Type, sets.List(attributeTypes)))
+		}
+	}
+
+	return allErrs
+}
+
+// ValidateDeviceClass validates a DeviceClass. The driver is the DeviceDriver
+// named in the class, if any. If it publishes an attribute schema, the
+// constraints are type-checked against it.
+func ValidateDeviceClass(class *api.DeviceClass, driver *api.DeviceDriver) field.ErrorList {
+	allErrs := apivalidation.ValidateObjectMeta(&class.ObjectMeta, false, apivalidation.NameIsDNSSubdomain, field.NewPath("metadata"))
+
+	fldPath := field.NewPath("spec")
+	if class.Spec.Driver == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("driver"), ""))
+	}
+	if class.Spec.DeviceType == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("deviceType"), ""))
+	}
+
+	if driver != nil && len(driver.Spec.Attributes) > 0 {
+		allErrs = append(allErrs, validateTypedConstraints(class.Spec.Constraints, driver, fldPath.Child("constraints"))...)
+	} else {
+		allErrs = append(allErrs, validateConstraints(class.Spec.Constraints, fldPath.Child("constraints"))...)
+	}
+
+	for i, c := range class.Spec.Configs {
+		idxPath := fldPath.Child("configs").Index(i)
+		allErrs = append(allErrs, validateRequiredStrings(idxPath, map[string]string{
+			"apiVersion": c.APIVersion,
+			"kind":       c.Kind,
+			"namespace":  c.Namespace,
+			"name":       c.Name,
+		})...)
+	}
+
+	return allErrs
+}
+
+// ValidateDeviceClaim validates a DeviceClaim.
+func ValidateDeviceClaim(claim *api.DeviceClaim) field.ErrorList {
+	allErrs := apivalidation.ValidateObjectMeta(&claim.ObjectMeta, true, apivalidation.NameIsDNSSubdomain, field.NewPath("metadata"))
+	allErrs = append(allErrs, ValidateDeviceClaimSpec(&claim.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, ValidateDeviceClaimStatus(&claim.Status, field.NewPath("status"))...)
+
+	return allErrs
+}
+
+// ValidateDevicePrivilegedClaim validates a DevicePrivilegedClaim.
+func ValidateDevicePrivilegedClaim(claim *api.DevicePrivilegedClaim) field.ErrorList {
+	allErrs := apivalidation.ValidateObjectMeta(&claim.ObjectMeta, true, apivalidation.NameIsDNSSubdomain, field.NewPath("metadata"))
+	allErrs = append(allErrs, ValidateDeviceClaimSpec(&claim.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, ValidateDeviceClaimStatus(&claim.Status, field.NewPath("status"))...)
+
+	return allErrs
+}
+
+// ValidateDeviceClaimSpec validates the spec of a DeviceClaim or
+// DevicePrivilegedClaim.
+func ValidateDeviceClaimSpec(spec *api.DeviceClaimSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	allErrs = append(allErrs, validateNames(spec.MatchAttributes, fldPath.Child("matchAttributes"))...)
+	allErrs = append(allErrs, validateDistinctAttributes(spec.DistinctAttributes, spec.MatchAttributes, fldPath.Child("distinctAttributes"))...)
+
+	if len(spec.Claims) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("claims"), "at least one claim is required"))
+	}
+
+	for i := range spec.Claims {
+		allErrs = append(allErrs, validateDeviceClaimInstance(&spec.Claims[i], fldPath.Child("claims").Index(i))...)
+	}
+
+	return allErrs
+}
+
+func validateDeviceClaimInstance(instance *api.DeviceClaimInstance, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	hasDetail := isDetailSet(&instance.DeviceClaimDetail)
+	hasOneOf := len(instance.OneOf) > 0
+
+	switch {
+	case hasDetail && hasOneOf:
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("oneOf"), "may not be set along with the claim details"))
+	case !hasDetail && !hasOneOf:
+		allErrs = append(allErrs, field.Required(fldPath, "either the claim details or oneOf must be set"))
+	case hasDetail:
+		allErrs = append(allErrs, validateDeviceClaimDetail(&instance.DeviceClaimDetail, fldPath)...)
+	default:
+		for i := range instance.OneOf {
+			allErrs = append(allErrs, validateDeviceClaimDetail(&instance.OneOf[i], fldPath.Child("oneOf").Index(i))...)
+		}
+	}
+
+	return allErrs
+}
+
+// isDetailSet returns true if any of the fields of the detail are populated.
+func isDetailSet(detail *api.DeviceClaimDetail) bool {
+	return detail.DeviceType != nil ||
+		detail.DeviceClass != nil ||
+		detail.Constraints != nil ||
+		len(detail.Requests) > 0 ||
+		len(detail.Limits) > 0 ||
+		len(detail.MatchAttributes) > 0 ||
+		len(detail.DistinctAttributes) > 0 ||
+		len(detail.Configs) > 0
+}
+
+func validateDeviceClaimDetail(detail *api.DeviceClaimDetail, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	switch {
+	case detail.DeviceType != nil && detail.DeviceClass != nil:
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("deviceType"), "may not be set along with deviceClass"))
+	case detail.DeviceType == nil && detail.DeviceClass == nil:
+		allErrs = append(allErrs, field.Required(fldPath, "one of deviceType or deviceClass must be set"))
+	case detail.DeviceType != nil && *detail.DeviceType == "":
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("deviceType"), "", "may not be empty"))
+	case detail.DeviceClass != nil && *detail.DeviceClass == "":
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("deviceClass"), "", "may not be empty"))
+	}
+
+	allErrs = append(allErrs, validateConstraints(detail.Constraints, fldPath.Child("constraints"))...)
+	allErrs = append(allErrs, validateQuantities(detail.Requests, fldPath.Child("requests"))...)
+	allErrs = append(allErrs, validateQuantities(detail.Limits, fldPath.Child("limits"))...)
+
+	if q, ok := detail.Requests[schedule.CountResource]; ok {
+		allErrs = append(allErrs, validateCount(q, fldPath.Child("requests").Key(schedule.CountResource))...)
+	}
+
+	if q, ok := detail.Limits[schedule.CountResource]; ok {
+		allErrs = append(allErrs, validateCount(q, fldPath.Child("limits").Key(schedule.CountResource))...)
+	}
+
+	for _, name := range sets.List(sets.KeySet(detail.Limits)) {
+		limit := detail.Limits[name]
+		if request, ok := detail.Requests[name]; ok && limit.Cmp(request) < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("limits").Key(name), limit.String(), fmt.Sprintf("must be greater than or equal to the request of %s", request.String())))
+		}
+	}
+
+	allErrs = append(allErrs, validateNames(detail.MatchAttributes, fldPath.Child("matchAttributes"))...)
+	allErrs = append(allErrs, validateDistinctAttributes(detail.DistinctAttributes, detail.MatchAttributes, fldPath.Child("distinctAttributes"))...)
+
+	for i, c := range detail.Configs {
+		idxPath := fldPath.Child("configs").Index(i)
+		allErrs = append(allErrs, validateRequiredStrings(idxPath, map[string]string{
+			"apiVersion": c.APIVersion,
+			"kind":       c.Kind,
+			"name":       c.Name,
+		})...)
+	}
+
+	return allErrs
+}
+
+func validateCount(q resource.Quantity, fldPath *field.Path) field.ErrorList {
+	count, ok := q.AsInt64()
+	if !ok || count < 1 {
+		return field.ErrorList{field.Invalid(fldPath, q.String(), "must be a whole number greater than zero")}
+	}
+
+	return nil
+}
+
+// ValidateDeviceClaimStatus validates the status of a DeviceClaim or
+// DevicePrivilegedClaim.
+func ValidateDeviceClaimStatus(status *api.DeviceClaimStatus, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, a := range status.Allocations {
+		idxPath := fldPath.Child("allocations").Index(i)
+		allErrs = append(allErrs, validateRequiredStrings(idxPath, map[string]string{
+			"devicePoolName": a.DevicePoolName,
+			"deviceName":     a.DeviceName,
+		})...)
+		allErrs = append(allErrs, validateResourceAllocations(a.Allocations, idxPath.Child("allocations"))...)
+	}
+
+	for i := range status.DeviceStatuses {
+		allErrs = append(allErrs, validateDeviceStatus(&status.DeviceStatuses[i], fldPath.Child("deviceStatuses").Index(i))...)
+	}
+
+	return allErrs
+}
+
+func validateDeviceStatus(status *api.DeviceStatus, fldPath *field.Path) field.ErrorList {
+	allErrs := validateRequiredStrings(fldPath, map[string]string{
+		"devicePoolName": status.DevicePoolName,
+		"deviceName":     status.DeviceName,
+	})
+
+	if len(status.DeviceIPs) > 0 {
+		ipPath := fldPath.Child("deviceIPs").Index(0).Child("ip")
+		switch {
+		case status.DeviceIP == nil:
+			allErrs = append(allErrs, field.Required(fldPath.Child("deviceIP"), "must be set when deviceIPs is populated"))
+		case *status.DeviceIP != status.DeviceIPs[0].IP:
+			allErrs = append(allErrs, field.Invalid(ipPath, status.DeviceIPs[0].IP, fmt.Sprintf("must match deviceIP %q", *status.DeviceIP)))
+		}
+	}
+
+	allErrs = append(allErrs, validateResourceAllocations(status.Allocations, fldPath.Child("allocations"))...)
+
+	return allErrs
+}
+
+func validateResourceAllocations(allocations []api.ResourceAllocation, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	names := sets.New[string]()
+	for i, a := range allocations {
+		idxPath := fldPath.Index(i)
+		if a.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+		} else if names.Has(a.Name) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), a.Name))
+		}
+		names.Insert(a.Name)
+
+		if a.Allocation.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("allocation"), a.Allocation.String(), "may not be negative"))
+		}
+	}
+
+	return allErrs
+}
+
+// ValidateDevicePool validates a DevicePool.
+func ValidateDevicePool(pool *api.DevicePool) field.ErrorList {
+	allErrs := apivalidation.ValidateObjectMeta(&pool.ObjectMeta, false, apivalidation.NameIsDNSSubdomain, field.NewPath("metadata"))
+
+	fldPath := field.NewPath("spec")
+	if pool.Spec.NodeName != nil && *pool.Spec.NodeName == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("nodeName"), "", "may not be empty if set"))
+	}
+
+	if pool.Spec.Driver == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("driver"), ""))
+	}
+
+	allErrs = append(allErrs, validateAttributes(pool.Spec.Attributes, fldPath.Child("attributes"))...)
+	allErrs = append(allErrs, validateResourceCapacities(pool.Spec.Resources, fldPath.Child("resources"))...)
+
+	poolResources := sets.New[string]()
+	for _, r := range pool.Spec.Resources {
+		poolResources.Insert(r.Name)
+	}
+
+	if len(pool.Spec.Devices) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("devices"), "at least one device is required"))
+	}
+
+	names := sets.New[string]()
+	for i := range pool.Spec.Devices {
+		d := &pool.Spec.Devices[i]
+		idxPath := fldPath.Child("devices").Index(i)
+		if d.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+		} else if names.Has(d.Name) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), d.Name))
+		}
+		names.Insert(d.Name)
+
+		allErrs = append(allErrs, validateAttributes(d.Attributes, idxPath.Child("attributes"))...)
+		allErrs = append(allErrs, validateQuantities(d.Requests, idxPath.Child("requests"))...)
+		for _, name := range sets.List(sets.KeySet(d.Requests)) {
+			if !poolResources.Has(name) {
+				allErrs = append(allErrs, field.NotFound(idxPath.Child("requests").Key(name), name))
+			}
+		}
+		allErrs = append(allErrs, validateResourceCapacities(d.Resources, idxPath.Child("resources"))...)
+	}
+
+	return allErrs
+}
+
+func validateAttributes(attrs []api.Attribute, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	// The cost of constraints is estimated assuming these limits.
+	if len(attrs) > schedule.MaxAttributes {
+		allErrs = append(allErrs, field.TooMany(fldPath, len(attrs), schedule.MaxAttributes))
+	}
+
+	names := sets.New[string]()
+	for i, a := range attrs {
+		idxPath := fldPath.Index(i)
+		if a.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+		} else if names.Has(a.Name) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), a.Name))
+		} else if reservedAttributeNames.Has(a.Name) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), a.Name, "reserved for use in constraints"))
+		}
+		names.Insert(a.Name)
+
+		var set []string
+		if a.StringValue != nil {
+			set = append(set, "stringValue")
+			if len(*a.StringValue) > schedule.MaxAttributeValueLength {
+				allErrs = append(allErrs, field.TooLong(idxPath.Child("stringValue"), "", schedule.MaxAttributeValueLength))
+			}
+		}
+		if a.IntValue != nil {
+			set = append(set, "intValue")
+		}
+		if a.QuantityValue != nil {
+			set = append(set, "quantityValue")
+		}
+		if a.SemVerValue != nil {
+			set = append(set, "semVerValue")
+			if _, err := schedule.ParseSemVer(*a.SemVerValue); err != nil {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("semVerValue"), *a.SemVerValue, err.Error()))
+			}
+		}
+
+		switch len(set) {
+		case 0:
+			allErrs = append(allErrs, field.Required(idxPath, "exactly one value must be set"))
+		case 1:
+		default:
+			allErrs = append(allErrs, field.Invalid(idxPath, set, "exactly one value must be set"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateResourceCapacities(capacities []api.ResourceCapacity, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	names := sets.New[string]()
+	for i, c := range capacities {
+		idxPath := fldPath.Index(i)
+		if c.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+		} else if names.Has(c.Name) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), c.Name))
+		}
+		names.Insert(c.Name)
+
+		if c.Capacity.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("capacity"), c.Capacity.String(), "may not be negative"))
+		}
+
+		if c.BlockSize != nil && c.BlockSize.Sign() <= 0 {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("blockSize"), c.BlockSize.String(), "must be greater than zero"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateConstraints(constraints *string, fldPath *field.Path) field.ErrorList {
+	if constraints == nil || *constraints == "" {
+		return nil
+	}
+
+	if err := schedule.CompileConstraints(*constraints); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, *constraints, err.Error())}
+	}
+
+	return nil
+}
+
+func validateTypedConstraints(constraints *string, driver *api.DeviceDriver, fldPath *field.Path) field.ErrorList {
+	if constraints == nil || *constraints == "" {
+		return nil
+	}
+
+	if err := schedule.CompileConstraintsForDriver(*constraints, driver.Spec.Attributes); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, *constraints, fmt.Sprintf("attributes of driver %q: %v", driver.Name, err))}
+	}
+
+	return nil
+}
+
+func validateQuantities(quantities map[string]resource.Quantity, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for _, name := range sets.List(sets.KeySet(quantities)) {
+		q := quantities[name]
+		if name == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath, name, "resource names may not be empty"))
+		}
+
+		if q.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(name), q.String(), "may not be negative"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateNames checks that a list of names has no empty or duplicate entries.
+func validateNames(names []string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	seen := sets.New[string]()
+	for i, n := range names {
+		if n == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), n, "may not be empty"))
+		} else if seen.Has(n) {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i), n))
+		}
+		seen.Insert(n)
+	}
+
+	return allErrs
+}
+
+// validateDistinctAttributes validates the names of distinct attributes. An
+// attribute cannot be both matched and distinct.
+func validateDistinctAttributes(names, matchNames []string, fldPath *field.Path) field.ErrorList {
+	allErrs := validateNames(names, fldPath)
+
+	match := sets.New(matchNames...)
+	for i, n := range names {
+		if match.Has(n) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), n, "may not also be in matchAttributes"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateRequiredStrings checks that each of the named fields is non-empty.
+func validateRequiredStrings(fldPath *field.Path, fields map[string]string) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for _, name := range sets.List(sets.KeySet(fields)) {
+		if fields[name] == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child(name), ""))
+		}
+	}
+
+	return allErrs
+}
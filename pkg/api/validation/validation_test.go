@@ -0,0 +1,527 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func ptr[T any](val T) *T {
+	var v T = val
+	return &v
+}
+
+// errorStrings returns the errors as strings, which makes test failures
+// easier to read.
+func errorStrings(errs field.ErrorList) []string {
+	var result []string
+	for _, err := range errs {
+		result = append(result, err.Error())
+	}
+	return result
+}
+
+func TestValidateDeviceDriver(t *testing.T) {
+	testCases := map[string]struct {
+		spec      api.DeviceDriverSpec
+		expErrors []string
+	}{
+		"valid": {
+			spec: api.DeviceDriverSpec{
+				DeviceTypes: []string{"gpu"},
+				Attributes: []api.AttributeSchema{
+					{Name: "vendor", Type: api.AttributeTypeString},
+					{Name: "numa", Type: api.AttributeTypeInt},
+				},
+			},
+		},
+		"missing device types": {
+			expErrors: []string{"spec.deviceTypes: Required value"},
+		},
+		"bad attribute schema": {
+			spec: api.DeviceDriverSpec{
+				DeviceTypes: []string{"gpu"},
+				Attributes: []api.AttributeSchema{
+					{Name: "vendor", Type: api.AttributeTypeString},
+					{Name: "vendor", Type: "bool"},
+					{Name: "driver", Type: api.AttributeTypeString},
+				},
+			},
+			expErrors: []string{
+				"spec.attributes[1].name: Duplicate value",
+				"spec.attributes[1].type: Unsupported value: \"bool\"",
+				"spec.attributes[2].name: Invalid value: \"driver\": reserved for use in constraints",
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			driver := &api.DeviceDriver{
+				ObjectMeta: metav1.ObjectMeta{Name: "mydriver"},
+				Spec:       tc.spec,
+			}
+			requireErrors(t, tc.expErrors, errorStrings(ValidateDeviceDriver(driver)))
+		})
+	}
+}
+
+func TestValidateDeviceClass(t *testing.T) {
+	foozer := &api.DeviceDriver{
+		ObjectMeta: metav1.ObjectMeta{Name: "example.com-foozer"},
+		Spec: api.DeviceDriverSpec{
+			DeviceTypes: []string{"gpu"},
+			Attributes: []api.AttributeSchema{
+				{Name: "vendor", Type: api.AttributeTypeString},
+				{Name: "memory", Type: api.AttributeTypeQuantity},
+				{Name: "firmwareVersion", Type: api.AttributeTypeSemVer},
+			},
+		},
+	}
+
+	testCases := map[string]struct {
+		spec      api.DeviceClassSpec
+		driver    *api.DeviceDriver
+		expErrors []string
+	}{
+		"valid": {
+			spec: api.DeviceClassSpec{
+				DeviceType:  "gpu",
+				Driver:      "example.com-foozer",
+				Constraints: ptr("device.vendor == 'example.com'"),
+			},
+		},
+		"missing device type": {
+			spec: api.DeviceClassSpec{
+				Driver: "example.com-foozer",
+			},
+			expErrors: []string{"spec.deviceType: Required value"},
+		},
+		"missing driver": {
+			spec: api.DeviceClassSpec{
+				DeviceType: "gpu",
+			},
+			expErrors: []string{"spec.driver: Required value"},
+		},
+		"invalid constraints": {
+			spec: api.DeviceClassSpec{
+				DeviceType:  "sriov-nic",
+				Driver:      "example.com-nic",
+				Constraints: ptr("device.bandwidth = '1G'"),
+			},
+			expErrors: []string{"spec.constraints: Invalid value"},
+		},
+		"constraints too expensive": {
+			spec: api.DeviceClassSpec{
+				DeviceType:  "gpu",
+				Driver:      "example.com-foozer",
+				Constraints: ptr("[1,2,3,4,5,6,7,8,9,10].all(x, [1,2,3,4,5,6,7,8,9,10].all(y, [1,2,3,4,5,6,7,8,9,10].all(z, x + y + z > 0)))"),
+			},
+			expErrors: []string{"spec.constraints: Invalid value: \"[1,2,3,4,5,6,7,8,9,10].all(x, [1,2,3,4,5,6,7,8,9,10].all(y, [1,2,3,4,5,6,7,8,9,10].all(z, x + y + z > 0)))\": estimated cost of 10551 exceeds the limit of 10000"},
+		},
+		"non-bool constraints": {
+			spec: api.DeviceClassSpec{
+				DeviceType:  "gpu",
+				Driver:      "example.com-foozer",
+				Constraints: ptr("'example.com'"),
+			},
+			expErrors: []string{"spec.constraints: Invalid value: \"'example.com'\": expression must evaluate to bool, not string"},
+		},
+		"typed constraints": {
+			spec: api.DeviceClassSpec{
+				DeviceType:  "gpu",
+				Driver:      "example.com-foozer",
+				Constraints: ptr("device.vendor == 'example.com' && device.memory >= quantity('10Gi') && device.firmwareVersion.major >= 1"),
+			},
+			driver: foozer,
+		},
+		"unknown attribute": {
+			spec: api.DeviceClassSpec{
+				DeviceType:  "gpu",
+				Driver:      "example.com-foozer",
+				Constraints: ptr("device.vendr == 'example.com'"),
+			},
+			driver:    foozer,
+			expErrors: []string{"spec.constraints: Invalid value: \"device.vendr == 'example.com'\": attributes of driver \"example.com-foozer\": ERROR: <input>:1:7: undefined field 'vendr'"},
+		},
+		"attribute used as the wrong type": {
+			spec: api.DeviceClassSpec{
+				DeviceType:  "gpu",
+				Driver:      "example.com-foozer",
+				Constraints: ptr("device.memory == '10Gi'"),
+			},
+			driver:    foozer,
+			expErrors: []string{"spec.constraints: Invalid value: \"device.memory == '10Gi'\": attributes of driver \"example.com-foozer\": ERROR: <input>:1:15: found no matching overload for '_==_' applied to '(k8srm.Quantity, string)'"},
+		},
+		"unknown attribute without a schema": {
+			spec: api.DeviceClassSpec{
+				DeviceType:  "gpu",
+				Driver:      "example.com-barzer",
+				Constraints: ptr("device.vendr == 'example.com'"),
+			},
+			driver: &api.DeviceDriver{
+				ObjectMeta: metav1.ObjectMeta{Name: "example.com-barzer"},
+				Spec:       api.DeviceDriverSpec{DeviceTypes: []string{"gpu"}},
+			},
+		},
+		"incomplete config reference": {
+			spec: api.DeviceClassSpec{
+				DeviceType: "vlan",
+				Driver:     "example.com-vlan",
+				Configs: []api.DeviceClassConfigReference{
+					{APIVersion: "v1", Kind: "ConfigMap", Name: "vlan-2000"},
+				},
+			},
+			expErrors: []string{"spec.configs[0].namespace: Required value"},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			class := &api.DeviceClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "myclass"},
+				Spec:       tc.spec,
+			}
+			requireErrors(t, tc.expErrors, errorStrings(ValidateDeviceClass(class, tc.driver)))
+		})
+	}
+}
+
+func TestValidateDeviceClaim(t *testing.T) {
+	testCases := map[string]struct {
+		spec      api.DeviceClaimSpec
+		status    api.DeviceClaimStatus
+		expErrors []string
+	}{
+		"valid": {
+			spec: api.DeviceClaimSpec{
+				MatchAttributes: []string{"numa"},
+				Claims: []api.DeviceClaimInstance{
+					{
+						DeviceClaimDetail: api.DeviceClaimDetail{
+							DeviceClass: ptr("example.com-foozer-set"),
+							Constraints: ptr("device.model == 'foozer-1000'"),
+							Requests:    map[string]resource.Quantity{"count": resource.MustParse("2")},
+							Limits:      map[string]resource.Quantity{"count": resource.MustParse("4")},
+						},
+					},
+					{
+						OneOf: []api.DeviceClaimDetail{
+							{DeviceClass: ptr("nvidia-a100")},
+							{DeviceType: ptr("gpu")},
+						},
+					},
+				},
+			},
+		},
+		"no claims": {
+			expErrors: []string{"spec.claims: Required value"},
+		},
+		"empty instance": {
+			spec: api.DeviceClaimSpec{
+				Claims: []api.DeviceClaimInstance{{}},
+			},
+			expErrors: []string{"spec.claims[0]: Required value: either the claim details or oneOf must be set"},
+		},
+		"detail and oneOf": {
+			spec: api.DeviceClaimSpec{
+				Claims: []api.DeviceClaimInstance{
+					{
+						DeviceClaimDetail: api.DeviceClaimDetail{
+							DeviceClass: ptr("nvidia-a100"),
+						},
+						OneOf: []api.DeviceClaimDetail{
+							{DeviceClass: ptr("nvidia-h100")},
+						},
+					},
+				},
+			},
+			expErrors: []string{"spec.claims[0].oneOf: Forbidden"},
+		},
+		"neither class nor type": {
+			spec: api.DeviceClaimSpec{
+				Claims: []api.DeviceClaimInstance{
+					{
+						DeviceClaimDetail: api.DeviceClaimDetail{
+							Constraints: ptr("device.model == 'foozer-1000'"),
+						},
+					},
+				},
+			},
+			expErrors: []string{"spec.claims[0]: Required value: one of deviceType or deviceClass must be set"},
+		},
+		"both class and type": {
+			spec: api.DeviceClaimSpec{
+				Claims: []api.DeviceClaimInstance{
+					{
+						DeviceClaimDetail: api.DeviceClaimDetail{
+							DeviceClass: ptr("nvidia-a100"),
+							DeviceType:  ptr("gpu"),
+						},
+					},
+				},
+			},
+			expErrors: []string{"spec.claims[0].deviceType: Forbidden"},
+		},
+		"invalid constraints in oneOf": {
+			spec: api.DeviceClaimSpec{
+				Claims: []api.DeviceClaimInstance{
+					{
+						OneOf: []api.DeviceClaimDetail{
+							{DeviceClass: ptr("nvidia-h100")},
+							{DeviceClass: ptr("nvidia-l4"), Constraints: ptr("device.model ==")},
+						},
+					},
+				},
+			},
+			expErrors: []string{"spec.claims[0].oneOf[1].constraints: Invalid value"},
+		},
+		"bad counts": {
+			spec: api.DeviceClaimSpec{
+				Claims: []api.DeviceClaimInstance{
+					{
+						DeviceClaimDetail: api.DeviceClaimDetail{
+							DeviceClass: ptr("nvidia-a100"),
+							Requests:    map[string]resource.Quantity{"count": resource.MustParse("1.5")},
+							Limits:      map[string]resource.Quantity{"count": resource.MustParse("1")},
+						},
+					},
+				},
+			},
+			expErrors: []string{
+				"spec.claims[0].requests[count]: Invalid value: \"1500m\": must be a whole number greater than zero",
+				"spec.claims[0].limits[count]: Invalid value: \"1\": must be greater than or equal to the request of 1500m",
+			},
+		},
+		"duplicate match attributes": {
+			spec: api.DeviceClaimSpec{
+				MatchAttributes: []string{"numa", "numa"},
+				Claims: []api.DeviceClaimInstance{
+					{
+						DeviceClaimDetail: api.DeviceClaimDetail{
+							DeviceClass: ptr("nvidia-a100"),
+						},
+					},
+				},
+			},
+			expErrors: []string{"spec.matchAttributes[1]: Duplicate value"},
+		},
+		"distinct attributes": {
+			spec: api.DeviceClaimSpec{
+				DistinctAttributes: []string{"numa"},
+				Claims: []api.DeviceClaimInstance{
+					{
+						DeviceClaimDetail: api.DeviceClaimDetail{
+							DeviceType:         ptr("sriov-vf"),
+							Requests:           map[string]resource.Quantity{"count": resource.MustParse("4")},
+							MatchAttributes:    []string{"vendor"},
+							DistinctAttributes: []string{"pf"},
+						},
+					},
+				},
+			},
+		},
+		"attribute both matched and distinct": {
+			spec: api.DeviceClaimSpec{
+				MatchAttributes:    []string{"numa"},
+				DistinctAttributes: []string{"numa", "numa"},
+				Claims: []api.DeviceClaimInstance{
+					{
+						DeviceClaimDetail: api.DeviceClaimDetail{
+							DeviceType:         ptr("sriov-vf"),
+							MatchAttributes:    []string{"pf"},
+							DistinctAttributes: []string{"pf", ""},
+						},
+					},
+				},
+			},
+			expErrors: []string{
+				"spec.distinctAttributes[1]: Duplicate value: \"numa\"",
+				"spec.distinctAttributes[0]: Invalid value: \"numa\": may not also be in matchAttributes",
+				"spec.distinctAttributes[1]: Invalid value: \"numa\": may not also be in matchAttributes",
+				"spec.claims[0].distinctAttributes[1]: Invalid value: \"\": may not be empty",
+				"spec.claims[0].distinctAttributes[0]: Invalid value: \"pf\": may not also be in matchAttributes",
+			},
+		},
+		"device IPs do not match device IP": {
+			spec: api.DeviceClaimSpec{
+				Claims: []api.DeviceClaimInstance{
+					{
+						DeviceClaimDetail: api.DeviceClaimDetail{
+							DeviceType: ptr("sriov-nic"),
+						},
+					},
+				},
+			},
+			status: api.DeviceClaimStatus{
+				DeviceStatuses: []api.DeviceStatus{
+					{
+						DevicePoolName: "mypool",
+						DeviceName:     "dev-00",
+						DeviceIP:       ptr("10.0.0.1"),
+						DeviceIPs:      []api.DeviceIP{{IP: "10.0.0.2"}, {IP: "10.0.0.1"}},
+					},
+				},
+			},
+			expErrors: []string{"status.deviceStatuses[0].deviceIPs[0].ip: Invalid value: \"10.0.0.2\": must match deviceIP \"10.0.0.1\""},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			claim := &api.DeviceClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "myclaim", Namespace: "default"},
+				Spec:       tc.spec,
+				Status:     tc.status,
+			}
+			requireErrors(t, tc.expErrors, errorStrings(ValidateDeviceClaim(claim)))
+		})
+	}
+}
+
+func TestValidateDevicePool(t *testing.T) {
+	testCases := map[string]struct {
+		spec      api.DevicePoolSpec
+		expErrors []string
+	}{
+		"valid": {
+			spec: api.DevicePoolSpec{
+				NodeName: ptr("node-00"),
+				Driver:   "example.com-foozer",
+				Attributes: []api.Attribute{
+					{Name: "vendor", StringValue: ptr("example.com")},
+					{Name: "firmwareVersion", SemVerValue: ptr(api.SemVer("1.8.2"))},
+				},
+				Resources: []api.ResourceCapacity{
+					{Name: "memory", Capacity: resource.MustParse("80Gi")},
+				},
+				Devices: []api.Device{
+					{
+						Name:     "dev-00",
+						Requests: map[string]resource.Quantity{"memory": resource.MustParse("40Gi")},
+					},
+					{
+						Name:       "dev-01",
+						Attributes: []api.Attribute{{Name: "numa", IntValue: ptr(1)}},
+					},
+				},
+			},
+		},
+		"missing driver and devices": {
+			spec: api.DevicePoolSpec{},
+			expErrors: []string{
+				"spec.driver: Required value",
+				"spec.devices: Required value",
+			},
+		},
+		"attribute with two values": {
+			spec: api.DevicePoolSpec{
+				Driver: "example.com-foozer",
+				Attributes: []api.Attribute{
+					{Name: "numa", StringValue: ptr("0"), IntValue: ptr(0)},
+				},
+				Devices: []api.Device{{Name: "dev-00"}},
+			},
+			expErrors: []string{"spec.attributes[0]: Invalid value: []string{\"stringValue\", \"intValue\"}: exactly one value must be set"},
+		},
+		"reserved attribute name": {
+			spec: api.DevicePoolSpec{
+				Driver: "example.com-foozer",
+				Devices: []api.Device{
+					{
+						Name:       "dev-00",
+						Attributes: []api.Attribute{{Name: "driver", StringValue: ptr("other")}},
+					},
+				},
+			},
+			expErrors: []string{"spec.devices[0].attributes[0].name: Invalid value: \"driver\": reserved for use in constraints"},
+		},
+		"attribute with no value": {
+			spec: api.DevicePoolSpec{
+				Driver: "example.com-foozer",
+				Devices: []api.Device{
+					{
+						Name:       "dev-00",
+						Attributes: []api.Attribute{{Name: "numa"}},
+					},
+				},
+			},
+			expErrors: []string{"spec.devices[0].attributes[0]: Required value"},
+		},
+		"invalid semantic version": {
+			spec: api.DevicePoolSpec{
+				Driver: "example.com-foozer",
+				Attributes: []api.Attribute{
+					{Name: "firmwareVersion", SemVerValue: ptr(api.SemVer("v1.8"))},
+				},
+				Devices: []api.Device{{Name: "dev-00"}},
+			},
+			expErrors: []string{"spec.attributes[0].semVerValue: Invalid value: \"v1.8\""},
+		},
+		"attribute too long": {
+			spec: api.DevicePoolSpec{
+				Driver: "example.com-foozer",
+				Attributes: []api.Attribute{
+					{Name: "model", StringValue: ptr(strings.Repeat("x", 257))},
+				},
+				Devices: []api.Device{{Name: "dev-00"}},
+			},
+			expErrors: []string{"spec.attributes[0].stringValue: Too long: must have at most 256 bytes"},
+		},
+		"duplicate devices and unknown pool resource": {
+			spec: api.DevicePoolSpec{
+				Driver: "example.com-foozer",
+				Devices: []api.Device{
+					{Name: "dev-00"},
+					{
+						Name:     "dev-00",
+						Requests: map[string]resource.Quantity{"memory": resource.MustParse("40Gi")},
+					},
+				},
+			},
+			expErrors: []string{
+				"spec.devices[1].name: Duplicate value",
+				"spec.devices[1].requests[memory]: Not found",
+			},
+		},
+		"bad block size": {
+			spec: api.DevicePoolSpec{
+				Driver: "example.com-foozer",
+				Devices: []api.Device{
+					{
+						Name: "dev-00",
+						Resources: []api.ResourceCapacity{
+							{Name: "memory", Capacity: resource.MustParse("40Gi"), BlockSize: ptr(resource.MustParse("0"))},
+						},
+					},
+				},
+			},
+			expErrors: []string{"spec.devices[0].resources[0].blockSize: Invalid value"},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			pool := &api.DevicePool{
+				ObjectMeta: metav1.ObjectMeta{Name: "mypool"},
+				Spec:       tc.spec,
+			}
+			requireErrors(t, tc.expErrors, errorStrings(ValidateDevicePool(pool)))
+		})
+	}
+}
+
+// requireErrors checks that each actual error starts with the corresponding
+// expected string.
+func requireErrors(t *testing.T, expected, actual []string) {
+	require.Len(t, actual, len(expected), "errors: %v", actual)
+	for i := range expected {
+		require.Contains(t, actual[i], expected[i])
+	}
+}
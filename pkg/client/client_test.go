@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"github.com/johnbelamaric/k8srm-prototype/pkg/gen"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/mockkubeapiserver"
+)
+
+func startServer(t *testing.T) *Clientset {
+	k8s, err := mockkubeapiserver.NewMockKubeAPIServer(":0")
+	require.NoError(t, err)
+
+	k8s.RegisterType(api.SchemeGroupVersion.WithKind("DeviceDriver"), "devicedrivers", meta.RESTScopeRoot)
+	k8s.RegisterType(api.SchemeGroupVersion.WithKind("DeviceClass"), "deviceclasses", meta.RESTScopeRoot)
+	k8s.RegisterType(api.SchemeGroupVersion.WithKind("DeviceClaim"), "deviceclaims", meta.RESTScopeNamespace)
+	k8s.RegisterType(api.SchemeGroupVersion.WithKind("DevicePrivilegedClaim"), "deviceprivilegedclaims", meta.RESTScopeNamespace)
+	k8s.RegisterType(api.SchemeGroupVersion.WithKind("DevicePool"), "devicepools", meta.RESTScopeRoot)
+
+	addr, err := k8s.StartServing()
+	require.NoError(t, err)
+	t.Cleanup(func() { k8s.Stop() })
+
+	cs, err := NewForConfig(&rest.Config{Host: "http://" + addr.String()})
+	require.NoError(t, err)
+
+	return cs
+}
+
+func TestClientset(t *testing.T) {
+	ctx := context.Background()
+	cs := startServer(t)
+
+	pools := gen.Gen("foozer-1000-small", 2)
+	for i := range pools {
+		_, err := cs.DevicePools().Create(ctx, &pools[i], metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	pool, err := cs.DevicePools().Get(ctx, "foozer-1000-small-01-foozer", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "example.com-foozer", pool.Spec.Driver)
+	require.Len(t, pool.Spec.Devices, 4)
+
+	poolList, err := cs.DevicePools().List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, poolList.Items, 2)
+
+	_, err = cs.DevicePools().Get(ctx, "missing", metav1.GetOptions{})
+	require.True(t, errors.IsNotFound(err), "error: %v", err)
+
+	claim := &api.DeviceClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "myclaim", Namespace: "default"},
+		Spec: api.DeviceClaimSpec{
+			Claims: []api.DeviceClaimInstance{
+				{DeviceClaimDetail: api.DeviceClaimDetail{DeviceClass: ptr("example.com-foozer")}},
+			},
+		},
+	}
+	claim, err = cs.DeviceClaims("default").Create(ctx, claim, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	claim.Status.PodNames = []string{"mypod"}
+	claim.Status.Allocations = []api.DeviceAllocation{{DevicePoolName: pool.Name, DeviceName: "dev-00"}}
+	_, err = cs.DeviceClaims("default").UpdateStatus(ctx, claim, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	claim, err = cs.DeviceClaims("default").Get(ctx, "myclaim", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"mypod"}, claim.Status.PodNames)
+	require.Len(t, claim.Status.Allocations, 1)
+
+	require.NoError(t, cs.DeviceClaims("default").Delete(ctx, "myclaim", metav1.DeleteOptions{}))
+	_, err = cs.DeviceClaims("default").Get(ctx, "myclaim", metav1.GetOptions{})
+	require.True(t, errors.IsNotFound(err), "error: %v", err)
+
+	// DeviceDrivers and DeviceClasses do not have a status subresource.
+	_, ok := cs.DeviceDrivers().(interface {
+		UpdateStatus(context.Context, *api.DeviceDriver, metav1.UpdateOptions) (*api.DeviceDriver, error)
+	})
+	require.False(t, ok)
+	_, ok = cs.DeviceClasses().(interface {
+		UpdateStatus(context.Context, *api.DeviceClass, metav1.UpdateOptions) (*api.DeviceClass, error)
+	})
+	require.False(t, ok)
+}
+
+func TestInformers(t *testing.T) {
+	ctx := context.Background()
+	cs := startServer(t)
+
+	pools := gen.Gen("foozer-1000-small", 1)
+	_, err := cs.DevicePools().Create(ctx, &pools[0], metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	claim := &api.DeviceClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "myclaim", Namespace: "default"},
+		Spec: api.DeviceClaimSpec{
+			Claims: []api.DeviceClaimInstance{
+				{DeviceClaimDetail: api.DeviceClaimDetail{DeviceType: ptr("gpu")}},
+			},
+		},
+	}
+	_, err = cs.DeviceClaims("default").Create(ctx, claim, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	factory := NewSharedInformerFactory(cs, 0)
+	poolLister := factory.DevicePools().Lister()
+	claimLister := factory.DeviceClaims().Lister()
+
+	stopCh := make(chan struct{})
+	defer func() {
+		close(stopCh)
+		factory.Shutdown()
+	}()
+	factory.Start(stopCh)
+	for typ, synced := range factory.WaitForCacheSync(stopCh) {
+		require.True(t, synced, "cache not synced for %s", typ)
+	}
+
+	pool, err := poolLister.Get("foozer-1000-small-00-foozer")
+	require.NoError(t, err)
+	require.Equal(t, "example.com-foozer", pool.Spec.Driver)
+
+	claims, err := claimLister.Namespace("default").List(labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, claims, 1)
+
+	_, err = claimLister.Namespace("other").Get("myclaim")
+	require.True(t, errors.IsNotFound(err), "error: %v", err)
+
+	// Objects created after the cache has synced arrive through the watch.
+	more := gen.Gen("foozer-4000-small", 1)
+	_, err = cs.DevicePools().Create(ctx, &more[0], metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	err = wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		pools, err := poolLister.List(labels.Everything())
+		return len(pools) == 2, err
+	})
+	require.NoError(t, err)
+}
+
+func ptr[T any](val T) *T {
+	var v T = val
+	return &v
+}
@@ -0,0 +1,107 @@
+// Package client provides a typed client, listers and shared informers for
+// the devmgmtproto.k8s.io API group, so that the scheduler and controllers can
+// work against an API server rather than YAML files.
+package client
+
+import (
+	"net/http"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+
+	"k8s.io/client-go/rest"
+)
+
+// Interface is the typed client for all device management kinds.
+type Interface interface {
+	RESTClient() rest.Interface
+	DeviceDrivers() DeviceDriverInterface
+	DeviceClasses() DeviceClassInterface
+	DeviceClaims(namespace string) DeviceClaimInterface
+	DevicePrivilegedClaims(namespace string) DevicePrivilegedClaimInterface
+	DevicePools() DevicePoolInterface
+}
+
+// The typed interfaces for each kind. DeviceDrivers and DeviceClasses do not
+// have a status subresource.
+type (
+	DeviceDriverInterface          = ResourceInterface[*api.DeviceDriver, *api.DeviceDriverList]
+	DeviceClassInterface           = ResourceInterface[*api.DeviceClass, *api.DeviceClassList]
+	DeviceClaimInterface           = StatusResourceInterface[*api.DeviceClaim, *api.DeviceClaimList]
+	DevicePrivilegedClaimInterface = StatusResourceInterface[*api.DevicePrivilegedClaim, *api.DevicePrivilegedClaimList]
+	DevicePoolInterface            = StatusResourceInterface[*api.DevicePool, *api.DevicePoolList]
+)
+
+// Clientset implements Interface.
+type Clientset struct {
+	restClient rest.Interface
+}
+
+var _ Interface = &Clientset{}
+
+// NewForConfig creates a new Clientset for the given config.
+func NewForConfig(c *rest.Config) (*Clientset, error) {
+	config := *c
+	setConfigDefaults(&config)
+
+	httpClient, err := rest.HTTPClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewForConfigAndClient(&config, httpClient)
+}
+
+// NewForConfigAndClient creates a new Clientset for the given config and http
+// client. The http client takes precedence over the configured transport.
+func NewForConfigAndClient(c *rest.Config, httpClient *http.Client) (*Clientset, error) {
+	config := *c
+	setConfigDefaults(&config)
+
+	restClient, err := rest.RESTClientForConfigAndClient(&config, httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(restClient), nil
+}
+
+// New creates a new Clientset for the given RESTClient.
+func New(c rest.Interface) *Clientset {
+	return &Clientset{restClient: c}
+}
+
+func setConfigDefaults(config *rest.Config) {
+	gv := api.SchemeGroupVersion
+	config.GroupVersion = &gv
+	config.APIPath = "/apis"
+	config.NegotiatedSerializer = Codecs.WithoutConversion()
+
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+}
+
+// RESTClient returns the underlying RESTClient.
+func (c *Clientset) RESTClient() rest.Interface {
+	return c.restClient
+}
+
+func (c *Clientset) DeviceDrivers() DeviceDriverInterface {
+	return newResourceClient[*api.DeviceDriver, *api.DeviceDriverList](c.restClient, "devicedrivers", "")
+}
+
+func (c *Clientset) DeviceClasses() DeviceClassInterface {
+	return newResourceClient[*api.DeviceClass, *api.DeviceClassList](c.restClient, "deviceclasses", "")
+}
+
+func (c *Clientset) DeviceClaims(namespace string) DeviceClaimInterface {
+	return newStatusResourceClient[*api.DeviceClaim, *api.DeviceClaimList](c.restClient, "deviceclaims", namespace)
+}
+
+func (c *Clientset) DevicePrivilegedClaims(namespace string) DevicePrivilegedClaimInterface {
+	return newStatusResourceClient[*api.DevicePrivilegedClaim, *api.DevicePrivilegedClaimList](c.restClient, "deviceprivilegedclaims", namespace)
+}
+
+func (c *Clientset) DevicePools() DevicePoolInterface {
+	return newStatusResourceClient[*api.DevicePool, *api.DevicePoolList](c.restClient, "devicepools", "")
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// The informers for each kind.
+type (
+	DeviceDriverInformer          = ClusterInformer[*api.DeviceDriver]
+	DeviceClassInformer           = ClusterInformer[*api.DeviceClass]
+	DeviceClaimInformer           = NamespacedInformer[*api.DeviceClaim]
+	DevicePrivilegedClaimInformer = NamespacedInformer[*api.DevicePrivilegedClaim]
+	DevicePoolInformer            = ClusterInformer[*api.DevicePool]
+)
+
+// SharedInformerFactory creates informers for the device management kinds,
+// sharing a single informer for each kind among all callers. Namespaced kinds
+// are watched across all namespaces.
+type SharedInformerFactory struct {
+	client        Interface
+	defaultResync time.Duration
+
+	lock      sync.Mutex
+	informers map[reflect.Type]cache.SharedIndexInformer
+	// startedInformers tracks which informers have been started, so that
+	// Start may be called again after adding new informers.
+	startedInformers map[reflect.Type]bool
+	wg               sync.WaitGroup
+	shuttingDown     bool
+}
+
+// NewSharedInformerFactory returns a new factory for the given client.
+func NewSharedInformerFactory(client Interface, defaultResync time.Duration) *SharedInformerFactory {
+	return &SharedInformerFactory{
+		client:           client,
+		defaultResync:    defaultResync,
+		informers:        make(map[reflect.Type]cache.SharedIndexInformer),
+		startedInformers: make(map[reflect.Type]bool),
+	}
+}
+
+// Start starts all the informers that have been requested so far and have
+// not yet been started. It does not block.
+func (f *SharedInformerFactory) Start(stopCh <-chan struct{}) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.shuttingDown {
+		return
+	}
+
+	for typ, informer := range f.informers {
+		if f.startedInformers[typ] {
+			continue
+		}
+
+		f.wg.Add(1)
+		informer := informer
+		go func() {
+			defer f.wg.Done()
+			informer.Run(stopCh)
+		}()
+		f.startedInformers[typ] = true
+	}
+}
+
+// Shutdown marks the factory as shutting down and waits for all the
+// informers to stop. The stop channel passed to Start must be closed first.
+func (f *SharedInformerFactory) Shutdown() {
+	f.lock.Lock()
+	f.shuttingDown = true
+	f.lock.Unlock()
+
+	f.wg.Wait()
+}
+
+// WaitForCacheSync blocks until the caches of all started informers have
+// synced, or the stop channel is closed. It returns the sync status of each
+// informer.
+func (f *SharedInformerFactory) WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool {
+	informers := func() map[reflect.Type]cache.SharedIndexInformer {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		informers := make(map[reflect.Type]cache.SharedIndexInformer)
+		for typ, informer := range f.informers {
+			if f.startedInformers[typ] {
+				informers[typ] = informer
+			}
+		}
+		return informers
+	}()
+
+	res := make(map[reflect.Type]bool)
+	for typ, informer := range informers {
+		res[typ] = cache.WaitForCacheSync(stopCh, informer.HasSynced)
+	}
+	return res
+}
+
+func (f *SharedInformerFactory) DeviceDrivers() DeviceDriverInformer {
+	return ClusterInformer[*api.DeviceDriver]{
+		informer: informerFor(f, f.client.DeviceDrivers()),
+		resource: "devicedrivers",
+	}
+}
+
+func (f *SharedInformerFactory) DeviceClasses() DeviceClassInformer {
+	return ClusterInformer[*api.DeviceClass]{
+		informer: informerFor(f, f.client.DeviceClasses()),
+		resource: "deviceclasses",
+	}
+}
+
+func (f *SharedInformerFactory) DeviceClaims() DeviceClaimInformer {
+	return NamespacedInformer[*api.DeviceClaim]{
+		informer: informerFor(f, f.client.DeviceClaims(metav1.NamespaceAll)),
+		resource: "deviceclaims",
+	}
+}
+
+func (f *SharedInformerFactory) DevicePrivilegedClaims() DevicePrivilegedClaimInformer {
+	return NamespacedInformer[*api.DevicePrivilegedClaim]{
+		informer: informerFor(f, f.client.DevicePrivilegedClaims(metav1.NamespaceAll)),
+		resource: "deviceprivilegedclaims",
+	}
+}
+
+func (f *SharedInformerFactory) DevicePools() DevicePoolInformer {
+	return ClusterInformer[*api.DevicePool]{
+		informer: informerFor(f, f.client.DevicePools()),
+		resource: "devicepools",
+	}
+}
+
+// informerFor returns the shared informer for objects of type T, creating it
+// if this is the first request for that type.
+func informerFor[T runtime.Object, L runtime.Object](f *SharedInformerFactory, c ResourceInterface[T, L]) cache.SharedIndexInformer {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if informer, ok := f.informers[typ]; ok {
+		return informer
+	}
+
+	lw := &cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			return c.List(context.TODO(), opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return c.Watch(context.TODO(), opts)
+		},
+	}
+
+	informer := cache.NewSharedIndexInformer(lw, newObject[T](), f.defaultResync, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	f.informers[typ] = informer
+	return informer
+}
+
+// ClusterInformer provides access to the shared informer and lister for a
+// cluster scoped kind.
+type ClusterInformer[T runtime.Object] struct {
+	informer cache.SharedIndexInformer
+	resource string
+}
+
+func (i ClusterInformer[T]) Informer() cache.SharedIndexInformer {
+	return i.informer
+}
+
+func (i ClusterInformer[T]) Lister() ClusterLister[T] {
+	return NewClusterLister[T](i.informer.GetIndexer(), i.resource)
+}
+
+// NamespacedInformer provides access to the shared informer and lister for a
+// namespaced kind.
+type NamespacedInformer[T runtime.Object] struct {
+	informer cache.SharedIndexInformer
+	resource string
+}
+
+func (i NamespacedInformer[T]) Informer() cache.SharedIndexInformer {
+	return i.informer
+}
+
+func (i NamespacedInformer[T]) Lister() NamespacedLister[T] {
+	return NewNamespacedLister[T](i.informer.GetIndexer(), i.resource)
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// The listers for each kind.
+type (
+	DeviceDriverLister          = ClusterLister[*api.DeviceDriver]
+	DeviceClassLister           = ClusterLister[*api.DeviceClass]
+	DeviceClaimLister           = NamespacedLister[*api.DeviceClaim]
+	DevicePrivilegedClaimLister = NamespacedLister[*api.DevicePrivilegedClaim]
+	DevicePoolLister            = ClusterLister[*api.DevicePool]
+)
+
+// ClusterLister lists cluster scoped objects of type T from an informer's
+// indexer. The objects returned are shared with the cache and must be
+// treated as read-only.
+type ClusterLister[T runtime.Object] struct {
+	indexer  cache.Indexer
+	resource string
+}
+
+// NewClusterLister returns a lister for a cluster scoped resource.
+func NewClusterLister[T runtime.Object](indexer cache.Indexer, resource string) ClusterLister[T] {
+	return ClusterLister[T]{indexer: indexer, resource: resource}
+}
+
+// List lists all the objects in the indexer that match the selector.
+func (l ClusterLister[T]) List(selector labels.Selector) ([]T, error) {
+	return listAll[T](l.indexer, selector)
+}
+
+// Get retrieves the object with the given name from the indexer.
+func (l ClusterLister[T]) Get(name string) (T, error) {
+	return getByKey[T](l.indexer, l.resource, name, name)
+}
+
+// NamespacedLister lists namespaced objects of type T from an informer's
+// indexer. The objects returned are shared with the cache and must be treated
+// as read-only.
+type NamespacedLister[T runtime.Object] struct {
+	indexer  cache.Indexer
+	resource string
+}
+
+// NewNamespacedLister returns a lister for a namespaced resource.
+func NewNamespacedLister[T runtime.Object](indexer cache.Indexer, resource string) NamespacedLister[T] {
+	return NamespacedLister[T]{indexer: indexer, resource: resource}
+}
+
+// List lists the objects in all namespaces that match the selector.
+func (l NamespacedLister[T]) List(selector labels.Selector) ([]T, error) {
+	return listAll[T](l.indexer, selector)
+}
+
+// Namespace returns a lister for the objects in one namespace.
+func (l NamespacedLister[T]) Namespace(namespace string) NamespaceLister[T] {
+	return NamespaceLister[T]{indexer: l.indexer, resource: l.resource, namespace: namespace}
+}
+
+// NamespaceLister lists the objects of type T in a single namespace.
+type NamespaceLister[T runtime.Object] struct {
+	indexer   cache.Indexer
+	resource  string
+	namespace string
+}
+
+// List lists the objects in the namespace that match the selector.
+func (l NamespaceLister[T]) List(selector labels.Selector) ([]T, error) {
+	var result []T
+	err := cache.ListAllByNamespace(l.indexer, l.namespace, selector, func(obj interface{}) {
+		result = append(result, obj.(T))
+	})
+	return result, err
+}
+
+// Get retrieves the object with the given name in the namespace.
+func (l NamespaceLister[T]) Get(name string) (T, error) {
+	return getByKey[T](l.indexer, l.resource, l.namespace+"/"+name, name)
+}
+
+func listAll[T runtime.Object](indexer cache.Indexer, selector labels.Selector) ([]T, error) {
+	var result []T
+	err := cache.ListAll(indexer, selector, func(obj interface{}) {
+		result = append(result, obj.(T))
+	})
+	return result, err
+}
+
+func getByKey[T runtime.Object](indexer cache.Indexer, resource, key, name string) (T, error) {
+	var result T
+	obj, exists, err := indexer.GetByKey(key)
+	if err != nil {
+		return result, err
+	}
+
+	if !exists {
+		return result, errors.NewNotFound(api.Resource(resource), name)
+	}
+
+	return obj.(T), nil
+}
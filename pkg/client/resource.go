@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/rest"
+)
+
+// ResourceInterface has the methods to work with a single kind, where T is
+// the object type and L is its list type. It matches the typed clients
+// produced by client-gen, so it can be swapped out for one later.
+type ResourceInterface[T runtime.Object, L runtime.Object] interface {
+	Create(ctx context.Context, obj T, opts metav1.CreateOptions) (T, error)
+	Update(ctx context.Context, obj T, opts metav1.UpdateOptions) (T, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (T, error)
+	List(ctx context.Context, opts metav1.ListOptions) (L, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (T, error)
+}
+
+// StatusResourceInterface adds UpdateStatus to ResourceInterface, for the
+// kinds that have a status subresource.
+type StatusResourceInterface[T runtime.Object, L runtime.Object] interface {
+	ResourceInterface[T, L]
+	UpdateStatus(ctx context.Context, obj T, opts metav1.UpdateOptions) (T, error)
+}
+
+// resourceClient implements ResourceInterface. The namespace is empty for
+// cluster scoped kinds, and for namespaced kinds when working across all
+// namespaces.
+type resourceClient[T runtime.Object, L runtime.Object] struct {
+	client    rest.Interface
+	resource  string
+	namespace string
+}
+
+func newResourceClient[T runtime.Object, L runtime.Object](c rest.Interface, resource, namespace string) *resourceClient[T, L] {
+	return &resourceClient[T, L]{
+		client:    c,
+		resource:  resource,
+		namespace: namespace,
+	}
+}
+
+// statusResourceClient implements StatusResourceInterface.
+type statusResourceClient[T runtime.Object, L runtime.Object] struct {
+	*resourceClient[T, L]
+}
+
+func newStatusResourceClient[T runtime.Object, L runtime.Object](c rest.Interface, resource, namespace string) *statusResourceClient[T, L] {
+	return &statusResourceClient[T, L]{newResourceClient[T, L](c, resource, namespace)}
+}
+
+// newObject returns a new, empty object of type O, which must be a pointer
+// to a struct.
+func newObject[O runtime.Object]() O {
+	return reflect.New(reflect.TypeOf((*O)(nil)).Elem().Elem()).Interface().(O)
+}
+
+func (c *resourceClient[T, L]) Create(ctx context.Context, obj T, opts metav1.CreateOptions) (T, error) {
+	result := newObject[T]()
+	err := c.client.Post().
+		NamespaceIfScoped(c.namespace, c.namespace != "").
+		Resource(c.resource).
+		VersionedParams(&opts, ParameterCodec).
+		Body(obj).
+		Do(ctx).
+		Into(result)
+	return result, err
+}
+
+func (c *resourceClient[T, L]) Update(ctx context.Context, obj T, opts metav1.UpdateOptions) (T, error) {
+	return c.update(ctx, obj, opts, "")
+}
+
+func (c *statusResourceClient[T, L]) UpdateStatus(ctx context.Context, obj T, opts metav1.UpdateOptions) (T, error) {
+	return c.update(ctx, obj, opts, "status")
+}
+
+func (c *resourceClient[T, L]) update(ctx context.Context, obj T, opts metav1.UpdateOptions, subresource string) (T, error) {
+	result := newObject[T]()
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return result, err
+	}
+
+	req := c.client.Put().
+		NamespaceIfScoped(c.namespace, c.namespace != "").
+		Resource(c.resource).
+		Name(objMeta.GetName())
+	if subresource != "" {
+		req = req.SubResource(subresource)
+	}
+
+	err = req.VersionedParams(&opts, ParameterCodec).
+		Body(obj).
+		Do(ctx).
+		Into(result)
+	return result, err
+}
+
+func (c *resourceClient[T, L]) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.client.Delete().
+		NamespaceIfScoped(c.namespace, c.namespace != "").
+		Resource(c.resource).
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+func (c *resourceClient[T, L]) Get(ctx context.Context, name string, opts metav1.GetOptions) (T, error) {
+	result := newObject[T]()
+	err := c.client.Get().
+		NamespaceIfScoped(c.namespace, c.namespace != "").
+		Resource(c.resource).
+		Name(name).
+		VersionedParams(&opts, ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return result, err
+}
+
+func (c *resourceClient[T, L]) List(ctx context.Context, opts metav1.ListOptions) (L, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+
+	result := newObject[L]()
+	err := c.client.Get().
+		NamespaceIfScoped(c.namespace, c.namespace != "").
+		Resource(c.resource).
+		VersionedParams(&opts, ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return result, err
+}
+
+func (c *resourceClient[T, L]) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+
+	opts.Watch = true
+	return c.client.Get().
+		NamespaceIfScoped(c.namespace, c.namespace != "").
+		Resource(c.resource).
+		VersionedParams(&opts, ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+func (c *resourceClient[T, L]) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (T, error) {
+	result := newObject[T]()
+	err := c.client.Patch(pt).
+		NamespaceIfScoped(c.namespace, c.namespace != "").
+		Resource(c.resource).
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return result, err
+}
@@ -95,7 +95,6 @@ func getGenerators() map[string]generator {
 func Gen(nodeType string, num int) []api.DevicePool {
 	generators := getGenerators()
 
-	fmt.Println(generators)
 	generate, ok := generators[nodeType]
 	if !ok {
 		return nil
@@ -108,7 +107,7 @@ func gen(num int, nodeBase, poolBase string, devicesPerNuma, numaNodes int, vend
 	var pools []api.DevicePool
 	for i := 0; i < num; i++ {
 		nodeName := fmt.Sprintf("%s-%02d", nodeBase, i)
-		poolName := fmt.Sprintf("%s-pool-%s", nodeBase, poolBase)
+		poolName := fmt.Sprintf("%s-%s", nodeName, poolBase)
 
 		pools = append(pools, genPool(nodeName, poolName, devicesPerNuma, numaNodes, vendor, driver, model, firmwareVer, driverVer))
 	}
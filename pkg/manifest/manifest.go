@@ -0,0 +1,85 @@
+// Package manifest reads the objects in YAML files, for the commands that work
+// from files rather than an API server.
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Load reads the given YAML files, and the YAML files directly within the
+// given directories. Each file may contain multiple documents. The load
+// function is called with the path of the file and the JSON form of each
+// document in it, in order; empty documents are skipped. Errors from reading
+// a file or from the load function are returned with the path of the file.
+func Load(paths []string, load func(path string, doc []byte) error) error {
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			if err := loadFile(p, load); err != nil {
+				return err
+			}
+			continue
+		}
+
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			ext := filepath.Ext(e.Name())
+			if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+				continue
+			}
+			if err := loadFile(filepath.Join(p, e.Name()), load); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func loadFile(path string, load func(path string, doc []byte) error) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(b)))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		j, err := yaml.YAMLToJSON(doc)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		j = bytes.TrimSpace(j)
+		if len(j) == 0 || string(j) == "null" {
+			continue
+		}
+
+		if err := load(path, j); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	write("a.yaml", "kind: A\n---\n---\nnull\n---\nkind: B\n")
+	write("b.yml", "- kind: C\n")
+	write("c.txt", "kind: D\n")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o755))
+	write("sub/d.yaml", "kind: E\n")
+	file := write("sub/e.yaml", "kind: F\n")
+
+	var docs []string
+	err := Load([]string{dir, file}, func(path string, doc []byte) error {
+		docs = append(docs, filepath.Base(path)+" "+string(doc))
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`a.yaml {"kind":"A"}`,
+		`a.yaml {"kind":"B"}`,
+		`b.yml [{"kind":"C"}]`,
+		`e.yaml {"kind":"F"}`,
+	}, docs)
+}
+
+func TestLoadErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("kind: A\n---\nkind: [\n"), 0o644))
+
+	err := Load([]string{path}, func(string, []byte) error { return nil })
+	require.ErrorContains(t, err, path+": ")
+
+	err = Load([]string{filepath.Join(t.TempDir(), "missing.yaml")}, func(string, []byte) error { return nil })
+	require.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package schedule
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+)
+
+const (
+	// programCacheSize is the maximum number of compiled expressions that
+	// are kept. Most clusters have far fewer distinct constraints than
+	// this, which come from the classes and claims.
+	programCacheSize = 1024
+)
+
+// programs caches the compiled constraint expressions.
+var programs = newProgramCache(programCacheSize)
+
+// CacheStats are the counters for the compiled expression cache.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	Size   int
+}
+
+// ProgramCacheStats returns the counters for the compiled expression cache.
+func ProgramCacheStats() CacheStats {
+	return programs.stats()
+}
+
+// programCache is a concurrency-safe cache of compiled expressions, keyed by
+// the expression. When it is full, the least recently used entry is evicted.
+// Compilation errors are cached too, so that an invalid constraint is not
+// recompiled for every device.
+type programCache struct {
+	mu      sync.Mutex
+	maxSize int
+	lru     *list.List
+	entries map[string]*list.Element
+	hits    uint64
+	misses  uint64
+}
+
+type programCacheEntry struct {
+	expr string
+	prog cel.Program
+	err  error
+}
+
+func newProgramCache(maxSize int) *programCache {
+	return &programCache{
+		maxSize: maxSize,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the compiled expression from the cache, calling compile to
+// add it if it is missing. The lock is not held while compiling, so the same
+// expression may occasionally be compiled more than once.
+func (c *programCache) get(expr string, compile func(string) (cel.Program, error)) (cel.Program, error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[expr]; ok {
+		c.hits++
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*programCacheEntry)
+		c.mu.Unlock()
+		return entry.prog, entry.err
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	prog, err := compile(expr)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[expr]; !ok {
+		c.entries[expr] = c.lru.PushFront(&programCacheEntry{expr: expr, prog: prog, err: err})
+		for c.lru.Len() > c.maxSize {
+			oldest := c.lru.Back()
+			c.lru.Remove(oldest)
+			delete(c.entries, oldest.Value.(*programCacheEntry).expr)
+		}
+	}
+
+	return prog, err
+}
+
+func (c *programCache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses, Size: c.lru.Len()}
+}
@@ -0,0 +1,150 @@
+package schedule
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"github.com/johnbelamaric/k8srm-prototype/pkg/gen"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgramCache(t *testing.T) {
+	c := newProgramCache(2)
+	compiles := 0
+	compile := func(expr string) (cel.Program, error) {
+		compiles++
+		return compileExprUncached(expr)
+	}
+
+	_, err := c.get("device.a == 'x'", compile)
+	require.NoError(t, err)
+	_, err = c.get("device.a == 'x'", compile)
+	require.NoError(t, err)
+	require.Equal(t, 1, compiles)
+	require.Equal(t, CacheStats{Hits: 1, Misses: 1, Size: 1}, c.stats())
+
+	// Errors are cached too.
+	_, err = c.get("device.a ==", compile)
+	require.Error(t, err)
+	_, err = c.get("device.a ==", compile)
+	require.Error(t, err)
+	require.Equal(t, 2, compiles)
+	require.Equal(t, CacheStats{Hits: 2, Misses: 2, Size: 2}, c.stats())
+
+	// Adding a third expression evicts the least recently used one.
+	_, err = c.get("device.b == 'y'", compile)
+	require.NoError(t, err)
+	require.Equal(t, CacheStats{Hits: 2, Misses: 3, Size: 2}, c.stats())
+
+	_, err = c.get("device.a ==", compile)
+	require.Error(t, err)
+	require.Equal(t, 3, compiles)
+
+	_, err = c.get("device.a == 'x'", compile)
+	require.NoError(t, err)
+	require.Equal(t, 4, compiles)
+	require.Equal(t, CacheStats{Hits: 3, Misses: 4, Size: 2}, c.stats())
+}
+
+func TestProgramCacheConcurrent(t *testing.T) {
+	c := newProgramCache(8)
+	attrs := []api.Attribute{{Name: "numa", IntValue: ptr(3)}}
+	inputs, err := attributesToInputs(attrs)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				prog, err := c.get(fmt.Sprintf("device.numa >= %d", j%10), compileExprUncached)
+				if !assertNoError(t, err) {
+					return
+				}
+				val, _, err := prog.Eval(map[string]any{DeviceVarName: inputs})
+				if !assertNoError(t, err) {
+					return
+				}
+				if val.Value() != (j%10 <= 3) {
+					t.Errorf("device.numa >= %d: got %v", j%10, val.Value())
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := c.stats()
+	require.Equal(t, uint64(1600), stats.Hits+stats.Misses)
+	require.Equal(t, 8, stats.Size)
+}
+
+func assertNoError(t *testing.T, err error) bool {
+	if err != nil {
+		t.Error(err)
+		return false
+	}
+	return true
+}
+
+var benchConstraints = "device.vendor == 'example.com' && device.model == 'foozer-1000' && device.firmwareVersion >= semver('1.8.0')"
+
+func BenchmarkMeetsConstraints(b *testing.B) {
+	attrs := gen.Gen("foozer-1000-small", 1)[0].Spec.Attributes
+	inputs, err := attributesToInputs(attrs)
+	require.NoError(b, err)
+	inputs = map[string]any{DeviceVarName: inputs}
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			prog, err := compileExprUncached(benchConstraints)
+			require.NoError(b, err)
+			_, _, err = prog.Eval(inputs)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ok, err := MeetsConstraints(&benchConstraints, attrs)
+			require.NoError(b, err)
+			require.True(b, ok)
+		}
+	})
+}
+
+func BenchmarkSelectNode(b *testing.B) {
+	cluster := Cluster{
+		Drivers: []api.DeviceDriver{driver("example.com-foozer", "gpu")},
+		Pools:   gen.Gen("foozer-1000-small", 1000),
+	}
+	claims := []api.DeviceClaim{
+		claimWithDetails("claim", api.DeviceClaimDetail{
+			DeviceType:  ptr("gpu"),
+			Constraints: &benchConstraints,
+			Requests:    count("2"),
+		}),
+	}
+
+	run := func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			allocs, _ := SelectNode(claims, nil, cluster)
+			require.Len(b, allocs, 2)
+		}
+	}
+
+	// A cache that cannot hold anything compiles the constraints for
+	// every device, as was done before there was a cache.
+	b.Run("uncached", func(b *testing.B) {
+		saved := programs
+		programs = newProgramCache(0)
+		defer func() { programs = saved }()
+		run(b)
+	})
+
+	b.Run("cached", run)
+}
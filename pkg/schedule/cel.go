@@ -1,29 +1,134 @@
 package schedule
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"sync"
 
 	"github.com/google/cel-go/cel"
+	"k8s.io/apimachinery/pkg/api/resource"
+
 	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
 )
 
 const (
 	DeviceVarName = "device"
+
+	// PoolVarName is the variable that holds the name, node name, driver
+	// and resources of the pool containing the device.
+	PoolVarName = "pool"
+
+	// DriverFieldName is the field of the device variable that holds the
+	// name of its driver.
+	DriverFieldName = "driver"
+
+	// ResourcesFieldName is the field of the device and pool variables
+	// that maps each resource name to the amount that remains available
+	// after existing allocations.
+	ResourcesFieldName = "resources"
+
+	// CapacityFieldName is the field of the device and pool variables
+	// that maps each resource name to its total capacity.
+	CapacityFieldName = "capacity"
 )
 
+// ReservedAttributeNames may not be used as attribute names, since they are
+// used for other fields of the device variable.
+var ReservedAttributeNames = []string{DriverFieldName, ResourcesFieldName, CapacityFieldName}
+
 func MeetsConstraints(constraints *string, attrs []api.Attribute) (bool, error) {
 	if constraints == nil || *constraints == "" {
 		return true, nil
 	}
 
+	device, err := attributesToInputs(attrs)
+	if err != nil {
+		return false, err
+	}
+
 	inputs := make(map[string]interface{})
-	inputs[DeviceVarName] = attributesToInputs(attrs)
+	inputs[DeviceVarName] = device
+
+	return evalExpr(*constraints, inputs)
+}
+
+// MeetsDeviceConstraints evaluates the constraints for a device in a pool. The
+// device variable has the effective attributes of the device, along with its
+// driver and resources, and the pool variable has the name, node name, driver
+// and resources of the pool. Nothing is considered to be allocated, so the
+// available resources are the same as the capacity.
+func MeetsDeviceConstraints(constraints *string, pool *api.DevicePool, device *api.Device) (bool, error) {
+	return meetsDeviceConstraints(constraints, pool, device, nil, nil)
+}
+
+// meetsDeviceConstraints is like MeetsDeviceConstraints, but subtracts the
+// resources already used from the pool and the device from their capacities.
+func meetsDeviceConstraints(constraints *string, pool *api.DevicePool, device *api.Device, poolUsed, deviceUsed map[string]resource.Quantity) (bool, error) {
+	if constraints == nil || *constraints == "" {
+		return true, nil
+	}
+
+	inputs, err := deviceInputs(pool, device, poolUsed, deviceUsed)
+	if err != nil {
+		return false, err
+	}
 
 	return evalExpr(*constraints, inputs)
 }
 
-func attributesToInputs(attributes []api.Attribute) map[string]interface{} {
+// CompileConstraints checks that the constraints expression is valid CEL that
+// evaluates to a bool, without evaluating it.
+func CompileConstraints(constraints string) error {
+	_, err := compileExpr(constraints)
+	return err
+}
+
+func deviceInputs(pool *api.DevicePool, device *api.Device, poolUsed, deviceUsed map[string]resource.Quantity) (map[string]interface{}, error) {
+	attrs, err := attributesToInputs(pool.Spec.DeviceAttributes(device))
+	if err != nil {
+		return nil, err
+	}
+	attrs[DriverFieldName] = pool.Spec.Driver
+	attrs[CapacityFieldName], attrs[ResourcesFieldName] = resourcesToInputs(device.Resources, deviceUsed)
+
+	nodeName := ""
+	if pool.Spec.NodeName != nil {
+		nodeName = *pool.Spec.NodeName
+	}
+	poolCapacity, poolAvailable := resourcesToInputs(pool.Spec.Resources, poolUsed)
+
+	return map[string]interface{}{
+		DeviceVarName: attrs,
+		PoolVarName: map[string]interface{}{
+			"name":             pool.Name,
+			"nodeName":         nodeName,
+			"driver":           pool.Spec.Driver,
+			CapacityFieldName:  poolCapacity,
+			ResourcesFieldName: poolAvailable,
+		},
+	}, nil
+}
+
+// resourcesToInputs returns the capacity of each resource, and the amount of
+// it that is left once the used amount is taken away.
+func resourcesToInputs(resources []api.ResourceCapacity, used map[string]resource.Quantity) (capacity, available map[string]interface{}) {
+	capacity = make(map[string]interface{}, len(resources))
+	available = make(map[string]interface{}, len(resources))
+	for _, r := range resources {
+		capacity[r.Name] = Quantity{r.Capacity}
+
+		q := r.Capacity.DeepCopy()
+		if u, ok := used[r.Name]; ok {
+			q.Sub(u)
+		}
+		available[r.Name] = Quantity{q}
+	}
+
+	return capacity, available
+}
+
+func attributesToInputs(attributes []api.Attribute) (map[string]interface{}, error) {
 	result := make(map[string]interface{}, len(attributes))
 
 	for _, a := range attributes {
@@ -32,13 +137,17 @@ func attributesToInputs(attributes []api.Attribute) map[string]interface{} {
 		} else if a.IntValue != nil {
 			result[a.Name] = *a.IntValue
 		} else if a.QuantityValue != nil {
-			result[a.Name] = *a.QuantityValue
+			result[a.Name] = Quantity{*a.QuantityValue}
 		} else if a.SemVerValue != nil {
-			result[a.Name] = *a.SemVerValue
+			v, err := ParseSemVer(*a.SemVerValue)
+			if err != nil {
+				return nil, fmt.Errorf("attribute %q: invalid semantic version %q: %w", a.Name, *a.SemVerValue, err)
+			}
+			result[a.Name] = v
 		}
 	}
 
-	return result
+	return result, nil
 }
 
 func evalExpr(expr string, inputs map[string]interface{}) (bool, error) {
@@ -47,9 +156,12 @@ func evalExpr(expr string, inputs map[string]interface{}) (bool, error) {
 		return false, err
 	}
 
-	val, _, err := prog.Eval(inputs)
+	ctx, cancel := context.WithTimeout(context.Background(), ConstraintsEvalTimeout)
+	defer cancel()
+
+	val, _, err := prog.ContextEval(ctx, inputs)
 	if err != nil {
-		return false, err
+		return false, evalError(err)
 	}
 
 	result, err := val.ConvertToNative(reflect.TypeOf(true))
@@ -65,28 +177,77 @@ func evalExpr(expr string, inputs map[string]interface{}) (bool, error) {
 	return s, nil
 }
 
-// compileExpr returns a compiled CEL expression.
-func compileExpr(expr string) (cel.Program, error) {
+// baseEnv has the libraries and the pool variable used by all constraint
+// expressions, but does not declare the device variable, so that it can be
+// extended with either an untyped or a typed device.
+var baseEnv = sync.OnceValues(func() (*cel.Env, error) {
 	var opts []cel.EnvOption
 	opts = append(opts, cel.HomogeneousAggregateLiterals())
 	opts = append(opts, cel.EagerlyValidateDeclarations(true), cel.DefaultUTCTimeZone(true))
-	opts = append(opts, cel.Variable(DeviceVarName, cel.DynType))
+	opts = append(opts, cel.Variable(PoolVarName, cel.MapType(cel.StringType, cel.DynType)))
+	opts = append(opts, QuantityLib(), SemVerLib(), DeviceLib())
 
-	env, err := cel.NewEnv(opts...)
+	return cel.NewEnv(opts...)
+})
+
+// celEnv is the environment shared by all constraint expressions, in which
+// the device attributes are not typed. It is only built once, since that is
+// expensive.
+var celEnv = sync.OnceValues(func() (*cel.Env, error) {
+	env, err := baseEnv()
 	if err != nil {
 		return nil, err
 	}
 
+	return env.Extend(cel.Variable(DeviceVarName, cel.DynType))
+})
+
+// compileExpr returns a compiled CEL expression. The same expression is
+// evaluated for many devices, so the result is cached.
+func compileExpr(expr string) (cel.Program, error) {
+	return programs.get(expr, compileExprUncached)
+}
+
+func compileExprUncached(expr string) (cel.Program, error) {
+	env, err := celEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return compileExprInEnv(env, expr)
+}
+
+func compileExprInEnv(env *cel.Env, expr string) (cel.Program, error) {
+	ast, err := checkExpr(env, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []cel.ProgramOption{cel.EvalOptions(cel.OptOptimize)}
+	opts = append(opts, costProgramOptions()...)
+	return env.Program(ast, opts...)
+}
+
+// checkExpr compiles the expression and checks that it evaluates to a bool,
+// and that its cost is within the limit.
+func checkExpr(env *cel.Env, expr string) (*cel.Ast, error) {
 	ast, issues := env.Compile(expr)
 	if issues != nil {
 		return nil, issues.Err()
 	}
 
-	_, err = cel.AstToCheckedExpr(ast)
+	_, err := cel.AstToCheckedExpr(ast)
 	if err != nil {
 		return nil, err
 	}
-	return env.Program(ast,
-		cel.EvalOptions(cel.OptOptimize),
-	)
+
+	if !ast.OutputType().IsAssignableType(cel.BoolType) {
+		return nil, fmt.Errorf("expression must evaluate to bool, not %s", ast.OutputType())
+	}
+
+	if err := checkCost(env, ast); err != nil {
+		return nil, err
+	}
+
+	return ast, nil
 }
@@ -1,12 +1,14 @@
 package schedule
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
 	"github.com/stretchr/testify/require"
 
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func ptr[T any](val T) *T {
@@ -77,7 +79,6 @@ func TestMeetsConstraints(t *testing.T) {
 			},
 			result: false,
 		},
-		//TODO: add CEL type conversion for resource.Quantity so this test below can be enabled
 		"quantity constraint met": {
 			constraints: ptr("device.memory >= '10Gi'"),
 			attrs: []api.Attribute{
@@ -86,9 +87,251 @@ func TestMeetsConstraints(t *testing.T) {
 					QuantityValue: ptr(resource.MustParse("10Gi")),
 				},
 			},
-			expErr: "unsupported conversion to ref.Val: (resource.Quantity){{10737418240 0} {<nil>} 10Gi BinarySI}",
 			result: true,
 		},
+		"quantity constraint failed": {
+			constraints: ptr("device.memory > quantity('10Gi')"),
+			attrs: []api.Attribute{
+				{
+					Name:          "memory",
+					QuantityValue: ptr(resource.MustParse("10240Mi")),
+				},
+			},
+			result: false,
+		},
+		"quantity equality across formats": {
+			constraints: ptr("device.memory == quantity('10240Mi') && device.bandwidth < quantity('1.5G')"),
+			attrs: []api.Attribute{
+				{
+					Name:          "memory",
+					QuantityValue: ptr(resource.MustParse("10Gi")),
+				},
+				{
+					Name:          "bandwidth",
+					QuantityValue: ptr(resource.MustParse("1000M")),
+				},
+			},
+			result: true,
+		},
+		"quantity arithmetic": {
+			constraints: ptr("device.memory - quantity('8Gi') >= quantity('512Mi') * 4 && device.memory + quantity('1Gi') <= quantity('11Gi')"),
+			attrs: []api.Attribute{
+				{
+					Name:          "memory",
+					QuantityValue: ptr(resource.MustParse("10Gi")),
+				},
+			},
+			result: true,
+		},
+		"quantity arithmetic equality": {
+			constraints: ptr("quantity('1Gi') * 2 == quantity('2Gi') && device.memory + quantity('1Gi') == quantity('11Gi') && device.memory - quantity('1Gi') != quantity('1Gi')"),
+			attrs: []api.Attribute{
+				{
+					Name:          "memory",
+					QuantityValue: ptr(resource.MustParse("10Gi")),
+				},
+			},
+			result: true,
+		},
+		"quantity compared to invalid string": {
+			constraints: ptr("device.memory >= '10 gigs'"),
+			attrs: []api.Attribute{
+				{
+					Name:          "memory",
+					QuantityValue: ptr(resource.MustParse("10Gi")),
+				},
+			},
+			expErr: `invalid quantity "10 gigs": quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'`,
+		},
+		"quantity equal to string": {
+			constraints: ptr("device.memory == '10240Mi' && !(device.memory != '10Gi') && device.memory != '1Gi'"),
+			attrs: []api.Attribute{
+				{
+					Name:          "memory",
+					QuantityValue: ptr(resource.MustParse("10Gi")),
+				},
+			},
+			result: true,
+		},
+		"string compared to quantity": {
+			constraints: ptr("'10Gi' <= device.memory && '1Gi' < device.memory && !('11Gi' <= device.memory) && '10240Mi' == device.memory && '1Gi' != device.memory"),
+			attrs: []api.Attribute{
+				{
+					Name:          "memory",
+					QuantityValue: ptr(resource.MustParse("10Gi")),
+				},
+			},
+			result: true,
+		},
+		"string compared to quantity failed": {
+			constraints: ptr("'11Gi' <= device.memory"),
+			attrs: []api.Attribute{
+				{
+					Name:          "memory",
+					QuantityValue: ptr(resource.MustParse("10Gi")),
+				},
+			},
+			result: false,
+		},
+		"invalid string equal to quantity": {
+			constraints: ptr("'10 gigs' == device.memory"),
+			attrs: []api.Attribute{
+				{
+					Name:          "memory",
+					QuantityValue: ptr(resource.MustParse("10Gi")),
+				},
+			},
+			expErr: `invalid quantity "10 gigs": quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'`,
+		},
+		"invalid quantity": {
+			constraints: ptr("device.memory >= quantity('10 gigs')"),
+			attrs: []api.Attribute{
+				{
+					Name:          "memory",
+					QuantityValue: ptr(resource.MustParse("10Gi")),
+				},
+			},
+			expErr: `invalid quantity "10 gigs": quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'`,
+		},
+		"semver constraint met": {
+			constraints: ptr("device.firmwareVersion >= semver('1.10.0')"),
+			attrs: []api.Attribute{
+				{
+					Name:        "firmwareVersion",
+					SemVerValue: ptr(api.SemVer("1.10.2")),
+				},
+			},
+			result: true,
+		},
+		"semver constraint failed": {
+			// Compared lexically as strings, 1.8.2 would be greater.
+			constraints: ptr("device.firmwareVersion >= semver('1.10.0')"),
+			attrs: []api.Attribute{
+				{
+					Name:        "firmwareVersion",
+					SemVerValue: ptr(api.SemVer("1.8.2")),
+				},
+			},
+			result: false,
+		},
+		"semver prerelease": {
+			constraints: ptr("device.driverVersion < semver('4.2.1') && device.driverVersion > semver('4.2.1-gen2') && device.driverVersion.prerelease == 'gen3'"),
+			attrs: []api.Attribute{
+				{
+					Name:        "driverVersion",
+					SemVerValue: ptr(api.SemVer("4.2.1-gen3")),
+				},
+			},
+			result: true,
+		},
+		"semver equality ignores build metadata": {
+			constraints: ptr("device.driverVersion == semver('4.2.1+build.7')"),
+			attrs: []api.Attribute{
+				{
+					Name:        "driverVersion",
+					SemVerValue: ptr(api.SemVer("4.2.1")),
+				},
+			},
+			result: true,
+		},
+		"semver equality of values": {
+			constraints: ptr("semver('1.8.2') == device.firmwareVersion && semver('1.8.2') != semver('1.8.3')"),
+			attrs: []api.Attribute{
+				{
+					Name:        "firmwareVersion",
+					SemVerValue: ptr(api.SemVer("1.8.2")),
+				},
+			},
+			result: true,
+		},
+		"semver fields and methods": {
+			constraints: ptr("semver('1.8.2').major == 1 && device.firmwareVersion.minor == 8 && device.firmwareVersion.isGreaterThan(semver('1.8.1')) && device.firmwareVersion.isLessThan(semver('2.0.0')) && device.firmwareVersion.compareTo(semver('1.8.2')) == 0"),
+			attrs: []api.Attribute{
+				{
+					Name:        "firmwareVersion",
+					SemVerValue: ptr(api.SemVer("1.8.2")),
+				},
+			},
+			result: true,
+		},
+		"semver compared to string": {
+			constraints: ptr("device.firmwareVersion >= '1.10.0'"),
+			attrs: []api.Attribute{
+				{
+					Name:        "firmwareVersion",
+					SemVerValue: ptr(api.SemVer("1.8.2")),
+				},
+			},
+			result: false,
+		},
+		"semver equal to string": {
+			constraints: ptr("device.firmwareVersion == '1.10.0' && device.firmwareVersion != '1.9.0' && !(device.firmwareVersion != '1.10.0+build.7')"),
+			attrs: []api.Attribute{
+				{
+					Name:        "firmwareVersion",
+					SemVerValue: ptr(api.SemVer("1.10.0")),
+				},
+			},
+			result: true,
+		},
+		"string compared to semver": {
+			constraints: ptr("'1.9.0' < device.firmwareVersion && '1.10.0' >= device.firmwareVersion && '1.10.0' == device.firmwareVersion && '1.9.0' != device.firmwareVersion"),
+			attrs: []api.Attribute{
+				{
+					Name:        "firmwareVersion",
+					SemVerValue: ptr(api.SemVer("1.10.0")),
+				},
+			},
+			result: true,
+		},
+		"string compared to semver failed": {
+			// Compared lexically as strings, 1.8.2 would be greater.
+			constraints: ptr("'1.10.0' <= device.firmwareVersion"),
+			attrs: []api.Attribute{
+				{
+					Name:        "firmwareVersion",
+					SemVerValue: ptr(api.SemVer("1.8.2")),
+				},
+			},
+			result: false,
+		},
+		"semver compared to invalid string": {
+			constraints: ptr("device.firmwareVersion >= '1.10'"),
+			attrs: []api.Attribute{
+				{
+					Name:        "firmwareVersion",
+					SemVerValue: ptr(api.SemVer("1.8.2")),
+				},
+			},
+			expErr: `invalid semantic version "1.10": No Major.Minor.Patch elements found`,
+		},
+		"invalid semver attribute": {
+			constraints: ptr("device.firmwareVersion >= semver('1.10.0')"),
+			attrs: []api.Attribute{
+				{
+					Name:        "firmwareVersion",
+					SemVerValue: ptr(api.SemVer("1.8")),
+				},
+			},
+			expErr: `attribute "firmwareVersion": invalid semantic version "1.8": No Major.Minor.Patch elements found`,
+		},
+		"estimated cost too high": {
+			constraints: ptr("device.all(a, device.all(b, device.all(c, a + b + c != '')))"),
+			expErr:      "estimated cost of 18446744073709551615 exceeds the limit of 10000; simplify the expression, or reduce the size of any lists or strings it uses",
+		},
+		"actual cost too high": {
+			// Attribute values longer than the limit used for the
+			// estimate are rejected by validation, but if one gets
+			// through, the evaluation is still cut short.
+			constraints: ptr("device.model.matches('^(foozer|barzer)-[0-9]+$')"),
+			attrs: []api.Attribute{
+				{
+					Name:        "model",
+					StringValue: ptr(strings.Repeat("x", 100000)),
+				},
+			},
+			expErr: "evaluation exceeded the cost limit of 10000",
+		},
 	}
 	for tn, tc := range testCases {
 		t.Run(tn, func(t *testing.T) {
@@ -102,3 +345,208 @@ func TestMeetsConstraints(t *testing.T) {
 		})
 	}
 }
+
+func TestCompileConstraintsForDriver(t *testing.T) {
+	schema := []api.AttributeSchema{
+		{Name: "vendor", Type: api.AttributeTypeString},
+		{Name: "numa", Type: api.AttributeTypeInt},
+		{Name: "memory", Type: api.AttributeTypeQuantity},
+		{Name: "firmwareVersion", Type: api.AttributeTypeSemVer},
+	}
+
+	testCases := map[string]struct {
+		constraints string
+		schema      []api.AttributeSchema
+		expErr      string
+	}{
+		"all types": {
+			constraints: "device.vendor == 'example.com' && device.numa == 0 && device.memory >= quantity('10Gi') && device.firmwareVersion.isGreaterThan(semver('1.0.0'))",
+			schema:      schema,
+		},
+		"arithmetic and equality": {
+			constraints: "quantity('1Gi') * 2 == quantity('2Gi') && device.memory + quantity('1Gi') == quantity('11Gi') && semver('1.8.2') == device.firmwareVersion",
+			schema:      schema,
+		},
+		"optional attribute": {
+			constraints: "!has(device.numa) || device.numa == 0",
+			schema:      schema,
+		},
+		"driver and pool": {
+			constraints: "device.driver == 'example.com-foozer' && pool.nodeName != ''",
+			schema:      schema,
+		},
+		"resources": {
+			constraints: "device.resources.memory >= quantity('40Gi') && pool.resources.memory <= pool.capacity.memory",
+			schema:      schema,
+		},
+		"resource compared to int": {
+			constraints: "device.resources.memory > 1",
+			schema:      schema,
+		},
+		"int compared to resource": {
+			constraints: "1 < device.resources.memory",
+			schema:      schema,
+		},
+		"resource compared to string": {
+			constraints: "device.resources.memory > '1Gi'",
+			schema:      schema,
+		},
+		"semver compared to string": {
+			constraints: "device.firmwareVersion >= '1.10.0'",
+			schema:      schema,
+		},
+		"string compared to resource": {
+			constraints: "'1Gi' < device.resources.memory",
+			schema:      schema,
+		},
+		"string compared to semver": {
+			constraints: "'1.9.0' < device.firmwareVersion",
+			schema:      schema,
+		},
+		"semver equal to string": {
+			constraints: "device.firmwareVersion == '1.10.0'",
+			schema:      schema,
+			expErr:      "found no matching overload for '_==_' applied to '(k8srm.SemVer, string)'",
+		},
+		"resource equal to string": {
+			constraints: "device.resources.memory == '1Gi'",
+			schema:      schema,
+			expErr:      "ERROR: <input>:1:25: found no matching overload for '_==_' applied to '(k8srm.Quantity, string)'",
+		},
+		"unknown attribute": {
+			constraints: "device.vendr == 'example.com'",
+			schema:      schema,
+			expErr:      "ERROR: <input>:1:7: undefined field 'vendr'",
+		},
+		"wrong type": {
+			constraints: "device.numa == '0'",
+			schema:      schema,
+			expErr:      "ERROR: <input>:1:13: found no matching overload for '_==_' applied to '(int, string)'",
+		},
+		"no schema": {
+			constraints: "device.vendr == 'example.com'",
+		},
+		"unknown attribute type": {
+			constraints: "device.vendor == 'example.com'",
+			schema:      []api.AttributeSchema{{Name: "vendor", Type: "bool"}},
+			expErr:      `attribute "vendor": unknown attribute type "bool"`,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			err := CompileConstraintsForDriver(tc.constraints, tc.schema)
+			if tc.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.expErr)
+			}
+		})
+	}
+
+	// A typed expression evaluates the same way as an untyped one.
+	env, err := schemaEnv(schema)
+	require.NoError(t, err)
+	prog, err := compileExprInEnv(env, testCases["optional attribute"].constraints+" && device.memory >= quantity('10Gi') && '10Gi' <= device.memory")
+	require.NoError(t, err)
+
+	for _, attrs := range [][]api.Attribute{
+		{{Name: "memory", QuantityValue: ptr(resource.MustParse("16Gi"))}},
+		{{Name: "memory", QuantityValue: ptr(resource.MustParse("16Gi"))}, {Name: "numa", IntValue: ptr(0)}},
+	} {
+		device, err := attributesToInputs(attrs)
+		require.NoError(t, err)
+		val, _, err := prog.Eval(map[string]any{DeviceVarName: device})
+		require.NoError(t, err)
+		require.Equal(t, true, val.Value())
+	}
+}
+
+func TestMeetsDeviceConstraints(t *testing.T) {
+	pool := &api.DevicePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-00-foozer"},
+		Spec: api.DevicePoolSpec{
+			NodeName: ptr("node-00"),
+			Driver:   "example.com-foozer",
+			Attributes: []api.Attribute{
+				{Name: "model", StringValue: ptr("foozer-1000")},
+				{Name: "firmwareVersion", SemVerValue: ptr(api.SemVer("1.8.2"))},
+			},
+			Resources: []api.ResourceCapacity{
+				{Name: "memory", Capacity: resource.MustParse("80Gi")},
+			},
+		},
+	}
+	device := &api.Device{
+		Name: "dev-00",
+		Attributes: []api.Attribute{
+			{Name: "firmwareVersion", SemVerValue: ptr(api.SemVer("1.9.0"))},
+		},
+		Resources: []api.ResourceCapacity{
+			{Name: "memory", Capacity: resource.MustParse("40Gi")},
+		},
+	}
+
+	testCases := map[string]struct {
+		constraints string
+		expErr      string
+		result      bool
+	}{
+		"pool attribute": {
+			constraints: "device.model == 'foozer-1000'",
+			result:      true,
+		},
+		"device attribute overrides pool attribute": {
+			constraints: "device.firmwareVersion == semver('1.9.0')",
+			result:      true,
+		},
+		"driver": {
+			constraints: "device.driver == 'example.com-foozer' && pool.driver == device.driver",
+			result:      true,
+		},
+		"pool and node name": {
+			constraints: "pool.name == 'node-00-foozer' && pool.nodeName.startsWith('node-')",
+			result:      true,
+		},
+		"pool and node name failed": {
+			constraints: "pool.nodeName == 'node-01'",
+			result:      false,
+		},
+		"unknown pool field": {
+			constraints: "pool.vendor == 'example.com'",
+			expErr:      "no such key: vendor",
+		},
+		"device resources": {
+			constraints: "device.resources.memory >= quantity('40Gi') && device.capacity.memory == quantity('40Gi')",
+			result:      true,
+		},
+		"pool resources": {
+			constraints: "pool.resources.memory == pool.capacity.memory && pool.capacity.memory > device.capacity.memory",
+			result:      true,
+		},
+		"resources failed": {
+			constraints: "device.resources.memory >= quantity('80Gi')",
+			result:      false,
+		},
+		"unknown resource": {
+			constraints: "device.resources.cores > quantity('1')",
+			expErr:      "no such key: cores",
+		},
+		"optional resource": {
+			constraints: "!('cores' in device.resources) || device.resources.cores > quantity('1')",
+			result:      true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			result, err := MeetsDeviceConstraints(&tc.constraints, pool, device)
+			if tc.expErr == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.result, result)
+			} else {
+				require.EqualError(t, err, tc.expErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker"
+	"github.com/google/cel-go/common"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/interpreter"
+)
+
+// Constraints are written by ordinary users, but are evaluated by the
+// scheduler for every candidate device, so their cost must be bounded. The
+// cost is the abstract measure used by CEL, which is roughly the number of
+// operations, with string and list operations scaled by their size.
+const (
+	// ConstraintsCostLimit is the maximum cost of evaluating a constraints
+	// expression for a single device. It applies both to the estimate
+	// made when the expression is compiled, and to the actual cost at
+	// evaluation time.
+	ConstraintsCostLimit = 10000
+
+	// ConstraintsEvalTimeout is the maximum time that evaluating a
+	// constraints expression for a single device may take. The cost limit
+	// should be reached long before this; it is a backstop.
+	ConstraintsEvalTimeout = 100 * time.Millisecond
+
+	// MaxAttributes is the maximum number of attributes in a pool or a
+	// device.
+	MaxAttributes = 64
+
+	// MaxAttributeValueLength is the maximum length of a string attribute
+	// value.
+	MaxAttributeValueLength = 256
+
+	// interruptCheckFrequency is the number of comprehension iterations
+	// between checks for the timeout.
+	interruptCheckFrequency = 100
+)
+
+// checkCost estimates the worst case cost of evaluating the expression, and
+// returns an error if it is over the limit.
+func checkCost(env *cel.Env, ast *cel.Ast) error {
+	est, err := env.EstimateCost(ast, costEstimator{})
+	if err != nil {
+		return err
+	}
+
+	if est.Max > ConstraintsCostLimit {
+		return fmt.Errorf("estimated cost of %d exceeds the limit of %d; simplify the expression, or reduce the size of any lists or strings it uses", est.Max, ConstraintsCostLimit)
+	}
+
+	return nil
+}
+
+// costProgramOptions enforce the cost limit and timeout at evaluation time.
+func costProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{
+		cel.CostTracking(costEstimator{}),
+		cel.CostLimit(ConstraintsCostLimit),
+		cel.InterruptCheckFrequency(interruptCheckFrequency),
+	}
+}
+
+// evalError turns the errors for exceeding the cost limit or timeout into
+// ones that say what happened.
+func evalError(err error) error {
+	var cancelled interpreter.EvalCancelledError
+	if !errors.As(err, &cancelled) {
+		return err
+	}
+
+	switch cancelled.Cause {
+	case interpreter.CostLimitExceeded:
+		return fmt.Errorf("evaluation exceeded the cost limit of %d", ConstraintsCostLimit)
+	case interpreter.ContextCancelled:
+		return fmt.Errorf("evaluation exceeded the time limit of %s", ConstraintsEvalTimeout)
+	}
+
+	return err
+}
+
+// costEstimator gives the sizes of the device attributes and pool fields,
+// which CEL cannot know, based on the limits enforced when pools are
+// validated. It also gives the cost of the functions of DeviceLib, both when
+// the expression is compiled and when it is evaluated.
+type costEstimator struct{}
+
+// scalarSize is the size of a Quantity or a SemVer. CEL does not know that
+// they are scalars, so without it the cost of comparing the result of a
+// function that returns one would be unbounded.
+var scalarSize = checker.SizeEstimate{Min: 1, Max: 1}
+
+// scalarOverloads are the overloads of QuantityLib and SemVerLib that return
+// a Quantity or a SemVer.
+var scalarOverloads = map[string]bool{
+	"string_to_quantity":    true,
+	"add_quantity":          true,
+	"subtract_quantity":     true,
+	"multiply_quantity_int": true,
+	"string_to_semver":      true,
+}
+
+func (costEstimator) EstimateSize(element checker.AstNode) *checker.SizeEstimate {
+	path := element.Path()
+	if len(path) == 0 || (path[0] != DeviceVarName && path[0] != PoolVarName) {
+		return nil
+	}
+
+	switch {
+	case len(path) > 1:
+		return &checker.SizeEstimate{Min: 0, Max: MaxAttributeValueLength}
+	case path[0] == DeviceVarName:
+		// A device has its own attributes plus those of its pool, and
+		// the driver and resources.
+		return &checker.SizeEstimate{Min: 0, Max: 2*MaxAttributes + 3}
+	default:
+		return &checker.SizeEstimate{Min: 5, Max: 5}
+	}
+}
+
+// EstimateCallCost estimates the functions of DeviceLib, in the same way as
+// CEL does for the similar standard functions, and those that return a
+// Quantity or a SemVer, which cost as much as the scalar operations of CEL.
+// The others are left to CEL.
+func (e costEstimator) EstimateCallCost(function, overloadID string, target *checker.AstNode, args []checker.AstNode) *checker.CallEstimate {
+	if scalarOverloads[overloadID] {
+		return &checker.CallEstimate{CostEstimate: checker.CostEstimate{Min: 1, Max: 1}, ResultSize: &scalarSize}
+	}
+
+	switch function {
+	case "attributeOr":
+		// The result is either the attribute or the default. Only a
+		// literal default has a size of its own.
+		size := checker.SizeEstimate{Min: 0, Max: MaxAttributeValueLength}
+		if len(args) == 2 && args[1].ComputedSize() != nil {
+			size = size.Union(*args[1].ComputedSize())
+		}
+		return &checker.CallEstimate{CostEstimate: checker.CostEstimate{Min: 1, Max: 1}, ResultSize: &size}
+	case "matchesRegex":
+		if target == nil || len(args) != 1 {
+			return nil
+		}
+		str := e.stringFormSize(*target).Add(checker.SizeEstimate{Min: 1, Max: 1}).MultiplyByCostFactor(common.StringTraversalCostFactor)
+		regex := e.sizeOf(args[0]).MultiplyByCostFactor(common.RegexStringLengthCostFactor)
+		return &checker.CallEstimate{CostEstimate: str.Multiply(regex)}
+	case "matchesGlob":
+		// Matching a pattern with several stars backtracks, so it is
+		// estimated like a regular expression.
+		if target == nil || len(args) != 1 {
+			return nil
+		}
+		str := e.stringFormSize(*target).Add(checker.SizeEstimate{Min: 1, Max: 1}).MultiplyByCostFactor(common.StringTraversalCostFactor)
+		pattern := e.sizeOf(args[0]).MultiplyByCostFactor(common.RegexStringLengthCostFactor)
+		return &checker.CallEstimate{CostEstimate: str.Multiply(pattern)}
+	case "csv":
+		if target == nil {
+			return nil
+		}
+		// Every other character may be a comma.
+		size := e.sizeOf(*target)
+		items := checker.SizeEstimate{Min: 0, Max: size.Max/2 + 1}
+		return &checker.CallEstimate{CostEstimate: size.MultiplyByCostFactor(common.StringTraversalCostFactor), ResultSize: &items}
+	case "csvContains":
+		if target == nil || len(args) != 1 {
+			return nil
+		}
+		cost := e.sizeOf(*target).Add(e.sizeOf(args[0])).MultiplyByCostFactor(common.StringTraversalCostFactor)
+		return &checker.CallEstimate{CostEstimate: cost}
+	}
+
+	return nil
+}
+
+// CallCost gives the actual cost of the functions of DeviceLib, in the same
+// way as EstimateCallCost, but for the actual sizes of the arguments. The
+// others are left to CEL.
+func (costEstimator) CallCost(function, overloadID string, args []ref.Val, result ref.Val) *uint64 {
+	var cost uint64
+	switch function {
+	case "attributeOr":
+		cost = 1
+	case "matchesRegex", "matchesGlob":
+		if len(args) != 2 {
+			return nil
+		}
+		str := traversalCost(actualSize(args[0])+1, common.StringTraversalCostFactor)
+		pattern := traversalCost(actualSize(args[1]), common.RegexStringLengthCostFactor)
+		cost = str * pattern
+	case "csv":
+		if len(args) != 1 {
+			return nil
+		}
+		cost = traversalCost(actualSize(args[0]), common.StringTraversalCostFactor)
+	case "csvContains":
+		if len(args) != 2 {
+			return nil
+		}
+		cost = traversalCost(actualSize(args[0])+actualSize(args[1]), common.StringTraversalCostFactor)
+	default:
+		return nil
+	}
+
+	return &cost
+}
+
+// actualSize returns the size of the string form of a value, or zero if it
+// does not have one.
+func actualSize(v ref.Val) uint64 {
+	s, _ := stringForm(v)
+	return uint64(len(s))
+}
+
+func traversalCost(size uint64, factor float64) uint64 {
+	return uint64(math.Ceil(float64(size) * factor))
+}
+
+// sizeOf returns the size of a node in the same way as CEL.
+func (e costEstimator) sizeOf(node checker.AstNode) checker.SizeEstimate {
+	if size := node.ComputedSize(); size != nil {
+		return *size
+	}
+	if size := e.EstimateSize(node); size != nil {
+		return *size
+	}
+	return checker.SizeEstimate{Min: 0, Max: math.MaxUint64}
+}
+
+// stringFormSize returns the size of the string form of a String, Int,
+// Quantity or SemVer. Only strings have a size of their own; the string forms
+// of the others are no longer than an attribute value.
+func (e costEstimator) stringFormSize(node checker.AstNode) checker.SizeEstimate {
+	if node.Type() == cel.StringType {
+		return e.sizeOf(node)
+	}
+	return checker.SizeEstimate{Min: 1, Max: MaxAttributeValueLength}
+}
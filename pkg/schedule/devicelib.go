@@ -0,0 +1,202 @@
+package schedule
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+// DeviceLib adds functions that are commonly needed to select devices:
+//
+//   - device.hasAttribute(name), which is true if the device has the named
+//     attribute, and device.attributeOr(name, default), which returns the
+//     attribute, or the default if the device does not have it
+//   - value.matchesRegex(regex) and value.matchesGlob(pattern), which match
+//     the string form of a String, Int, Quantity or SemVer value against an
+//     RE2 regular expression, as with the standard matches(), or a shell
+//     pattern, as in path.Match; e.g., device.model.matchesGlob('foozer-*')
+//   - value.csv() and value.csvContains(item), which treat a String value as
+//     a comma-separated set of items
+//   - the comparison operators between a Quantity and an Int, in either
+//     order; e.g., device.memory > 0 or 1 < device.memory
+//
+// When the device attributes are not typed, == and != also compare a Quantity
+// and an Int by value. When they are typed, such an expression does not
+// compile, since the CEL equality operators only apply to values of the same
+// type.
+func DeviceLib() cel.EnvOption {
+	return cel.Lib(deviceLib{})
+}
+
+type deviceLib struct{}
+
+func (deviceLib) LibraryName() string {
+	return "k8srm.device"
+}
+
+func (deviceLib) CompileOptions() []cel.EnvOption {
+	paramA := cel.TypeParamType("A")
+
+	// stringForms declares an overload of a member function for each
+	// type with a string form.
+	stringForms := func(prefix string, impl func(ref.Val, ref.Val) ref.Val) []cel.FunctionOpt {
+		var opts []cel.FunctionOpt
+		for _, t := range []struct {
+			name string
+			typ  *cel.Type
+		}{
+			{"string", cel.StringType},
+			{"int", cel.IntType},
+			{"quantity", QuantityType},
+			{"semver", SemVerType},
+		} {
+			opts = append(opts, cel.MemberOverload(t.name+"_"+prefix, []*cel.Type{t.typ, cel.StringType}, cel.BoolType, cel.BinaryBinding(impl)))
+		}
+		return opts
+	}
+
+	// The comparison operators between a Quantity and an Int only need
+	// declarations. At runtime, they are dispatched to the Compare method
+	// of Quantity, with the operands reversed if need be. The equality
+	// operators cannot be declared for them, since their declarations
+	// would overlap with the standard ones.
+	intComparison := func(name, op string) cel.EnvOption {
+		return cel.Function(op,
+			cel.Overload(name+"_quantity_int", []*cel.Type{QuantityType, cel.IntType}, cel.BoolType),
+			cel.Overload(name+"_int_quantity", []*cel.Type{cel.IntType, QuantityType}, cel.BoolType),
+		)
+	}
+
+	return []cel.EnvOption{
+		cel.Function("hasAttribute",
+			cel.MemberOverload("dyn_has_attribute", []*cel.Type{cel.DynType, cel.StringType}, cel.BoolType,
+				cel.BinaryBinding(hasAttribute)),
+		),
+		cel.Function("attributeOr",
+			cel.MemberOverload("dyn_attribute_or", []*cel.Type{cel.DynType, cel.StringType, paramA}, paramA,
+				cel.FunctionBinding(attributeOr)),
+		),
+		cel.Function("matchesRegex", stringForms("matches_regex", matchesRegex)...),
+		cel.Function("matchesGlob", stringForms("matches_glob", matchesGlob)...),
+		cel.Function("csv",
+			cel.MemberOverload("string_csv", []*cel.Type{cel.StringType}, cel.ListType(cel.StringType),
+				cel.UnaryBinding(func(arg ref.Val) ref.Val {
+					s, ok := arg.(types.String)
+					if !ok {
+						return types.MaybeNoSuchOverloadErr(arg)
+					}
+					return types.DefaultTypeAdapter.NativeToValue(splitCSV(string(s)))
+				})),
+		),
+		cel.Function("csvContains",
+			cel.MemberOverload("string_csv_contains", []*cel.Type{cel.StringType, cel.StringType}, cel.BoolType,
+				cel.BinaryBinding(csvContains)),
+		),
+		intComparison("less", operators.Less),
+		intComparison("less_equals", operators.LessEquals),
+		intComparison("greater", operators.Greater),
+		intComparison("greater_equals", operators.GreaterEquals),
+	}
+}
+
+func (deviceLib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{cel.CustomDecorator(decorateReversedOperators)}
+}
+
+func hasAttribute(device, name ref.Val) ref.Val {
+	m, ok := device.(traits.Mapper)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(device)
+	}
+	_, found := m.Find(name)
+	return types.Bool(found)
+}
+
+func attributeOr(args ...ref.Val) ref.Val {
+	m, ok := args[0].(traits.Mapper)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(args[0])
+	}
+
+	v, found := m.Find(args[1])
+	if !found {
+		return args[2]
+	}
+	if v.Type() != args[2].Type() {
+		return types.NewErr("attribute %s is a %s, but the default is a %s", args[1], v.Type().TypeName(), args[2].Type().TypeName())
+	}
+	return v
+}
+
+// stringForm returns the string form of a String, Int, Quantity or SemVer.
+func stringForm(v ref.Val) (string, bool) {
+	switch v := v.(type) {
+	case types.String:
+		return string(v), true
+	case types.Int, Quantity, SemVer:
+		s, ok := v.ConvertToType(types.StringType).(types.String)
+		return string(s), ok
+	}
+	return "", false
+}
+
+func matchesRegex(value, pattern ref.Val) ref.Val {
+	s, ok := stringForm(value)
+	p, pok := pattern.(types.String)
+	if !ok || !pok {
+		return types.MaybeNoSuchOverloadErr(value)
+	}
+
+	re, err := regexp.Compile(string(p))
+	if err != nil {
+		return types.NewErr("invalid regular expression %q: %v", string(p), err)
+	}
+	return types.Bool(re.MatchString(s))
+}
+
+func matchesGlob(value, pattern ref.Val) ref.Val {
+	s, ok := stringForm(value)
+	p, pok := pattern.(types.String)
+	if !ok || !pok {
+		return types.MaybeNoSuchOverloadErr(value)
+	}
+
+	matched, err := path.Match(string(p), s)
+	if err != nil {
+		return types.NewErr("invalid glob pattern %q: %v", string(p), err)
+	}
+	return types.Bool(matched)
+}
+
+// splitCSV splits a comma-separated string, ignoring spaces around the items
+// and empty items.
+func splitCSV(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func csvContains(value, item ref.Val) ref.Val {
+	s, ok := value.(types.String)
+	i, iok := item.(types.String)
+	if !ok || !iok {
+		return types.MaybeNoSuchOverloadErr(value)
+	}
+
+	for _, x := range splitCSV(string(s)) {
+		if x == strings.TrimSpace(string(i)) {
+			return types.True
+		}
+	}
+	return types.False
+}
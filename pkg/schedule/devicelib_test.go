@@ -0,0 +1,322 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// deviceLibAttrs has an attribute of each type, for the conformance tests of
+// the device library.
+var deviceLibAttrs = []api.Attribute{
+	{Name: "model", StringValue: ptr("foozer-1000")},
+	{Name: "features", StringValue: ptr("fp16, int8,bf16")},
+	{Name: "numa", IntValue: ptr(1)},
+	{Name: "memory", QuantityValue: ptr(resource.MustParse("40Gi"))},
+	{Name: "firmwareVersion", SemVerValue: ptr(api.SemVer("1.8.2"))},
+}
+
+func TestDeviceLib(t *testing.T) {
+	testCases := map[string]struct {
+		constraints string
+		expErr      string
+		result      bool
+	}{
+		// hasAttribute
+		"hasAttribute string": {
+			constraints: "device.hasAttribute('model')",
+			result:      true,
+		},
+		"hasAttribute int": {
+			constraints: "device.hasAttribute('numa')",
+			result:      true,
+		},
+		"hasAttribute quantity": {
+			constraints: "device.hasAttribute('memory')",
+			result:      true,
+		},
+		"hasAttribute semver": {
+			constraints: "device.hasAttribute('firmwareVersion')",
+			result:      true,
+		},
+		"hasAttribute missing": {
+			constraints: "device.hasAttribute('vendor')",
+			result:      false,
+		},
+		"hasAttribute guards a missing attribute": {
+			constraints: "!device.hasAttribute('vendor') || device.vendor == 'example.com'",
+			result:      true,
+		},
+
+		// attributeOr
+		"attributeOr string": {
+			constraints: "device.attributeOr('model', 'none') == 'foozer-1000' && device.attributeOr('vendor', 'none') == 'none'",
+			result:      true,
+		},
+		"attributeOr int": {
+			constraints: "device.attributeOr('numa', 0) == 1 && device.attributeOr('cores', 0) == 0",
+			result:      true,
+		},
+		"attributeOr quantity": {
+			constraints: "device.attributeOr('memory', quantity('0')) == quantity('40Gi') && device.attributeOr('cache', quantity('0')) == quantity('0')",
+			result:      true,
+		},
+		"attributeOr semver": {
+			constraints: "device.attributeOr('firmwareVersion', semver('0.0.0')) == semver('1.8.2') && device.attributeOr('driverVersion', semver('0.0.0')) == semver('0.0.0')",
+			result:      true,
+		},
+		"attributeOr wrong type": {
+			constraints: "device.attributeOr('numa', 'none') == 'none'",
+			expErr:      "attribute numa is a int, but the default is a string",
+		},
+
+		// matchesRegex
+		"matchesRegex string": {
+			constraints: "device.model.matchesRegex('^foozer-[0-9]+$')",
+			result:      true,
+		},
+		"matchesRegex string failed": {
+			constraints: "device.model.matchesRegex('^barzer-')",
+			result:      false,
+		},
+		"matchesRegex int": {
+			constraints: "device.numa.matchesRegex('^[01]$')",
+			result:      true,
+		},
+		"matchesRegex quantity": {
+			constraints: "device.memory.matchesRegex('Gi$')",
+			result:      true,
+		},
+		"matchesRegex semver": {
+			constraints: "device.firmwareVersion.matchesRegex('^1\\\\.8\\\\.')",
+			result:      true,
+		},
+		"matchesRegex invalid": {
+			constraints: "device.model.matchesRegex('(')",
+			expErr:      "invalid regular expression \"(\": error parsing regexp: missing closing ): `(`",
+		},
+
+		// matchesGlob
+		"matchesGlob string": {
+			constraints: "device.model.matchesGlob('foozer-*')",
+			result:      true,
+		},
+		"matchesGlob string failed": {
+			constraints: "device.model.matchesGlob('foozer-4???')",
+			result:      false,
+		},
+		"matchesGlob int": {
+			constraints: "device.numa.matchesGlob('[0-1]')",
+			result:      true,
+		},
+		"matchesGlob quantity": {
+			constraints: "device.memory.matchesGlob('*Gi')",
+			result:      true,
+		},
+		"matchesGlob semver": {
+			constraints: "device.firmwareVersion.matchesGlob('1.8.*')",
+			result:      true,
+		},
+		"matchesGlob invalid": {
+			constraints: "device.model.matchesGlob('[')",
+			expErr:      "invalid glob pattern \"[\": syntax error in pattern",
+		},
+
+		// csv and csvContains
+		"csv string": {
+			constraints: "device.features.csv() == ['fp16', 'int8', 'bf16'] && 'int8' in device.features.csv()",
+			result:      true,
+		},
+		"csv string with one item": {
+			constraints: "device.model.csv() == ['foozer-1000']",
+			result:      true,
+		},
+		"csvContains string": {
+			constraints: "device.features.csvContains('bf16') && !device.features.csvContains('fp8')",
+			result:      true,
+		},
+		"csv int": {
+			constraints: "device.numa.csv().size() == 1",
+			expErr:      "no such overload",
+		},
+		"csvContains int": {
+			constraints: "device.numa.csvContains('1')",
+			expErr:      "no such overload",
+		},
+		"csvContains quantity": {
+			constraints: "device.memory.csvContains('40Gi')",
+			expErr:      "no such overload",
+		},
+		"csvContains semver": {
+			constraints: "device.firmwareVersion.csvContains('1.8.2')",
+			expErr:      "no such overload",
+		},
+
+		"nested csv too costly": {
+			constraints: "device.features.csv().all(a, device.features.csv().exists(b, a != b))",
+			expErr:      "estimated cost of",
+		},
+
+		// Quantity and Int comparison
+		"quantity compared to int": {
+			constraints: "device.memory > 0 && device.memory >= 42949672960 && device.memory <= 42949672960 && device.memory < 42949672961",
+			result:      true,
+		},
+		"quantity compared to int failed": {
+			constraints: "device.memory < 1024",
+			result:      false,
+		},
+		"fractional quantity compared to int": {
+			constraints: "quantity('1.5') > 1 && quantity('500m') < 1",
+			result:      true,
+		},
+		"int compared to quantity": {
+			constraints: "0 < device.memory && 42949672960 <= device.memory && 42949672960 >= device.memory && 42949672961 > device.memory && device.numa < quantity('2')",
+			result:      true,
+		},
+		"int compared to quantity failed": {
+			constraints: "1024 > device.memory",
+			result:      false,
+		},
+		"fractional quantity compared to int from the left": {
+			constraints: "1 < quantity('1.5') && 1 > quantity('500m')",
+			result:      true,
+		},
+		"quantity equal to int": {
+			constraints: "device.memory == 42949672960 && 42949672960 == device.memory",
+			result:      true,
+		},
+		"quantity not equal to int": {
+			constraints: "device.memory != 2 && 2 != device.memory && !(device.memory == 2) && !(2 == device.memory)",
+			result:      true,
+		},
+		"equality of other types": {
+			constraints: "device.numa == 1 && device.model != 'foozer' && device.memory == quantity('40Gi') && device.numa != 'one'",
+			result:      true,
+		},
+		"comparison of other types": {
+			constraints: "device.numa < 2 && device.model > 'foozer' && device.memory >= quantity('40Gi')",
+			result:      true,
+		},
+		"semver compared to int": {
+			constraints: "device.firmwareVersion > 1",
+			expErr:      "no such overload",
+		},
+		"string compared to int": {
+			constraints: "device.model > 1",
+			expErr:      "no such overload",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			result, err := MeetsConstraints(&tc.constraints, deviceLibAttrs)
+			if tc.expErr == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.result, result)
+			} else {
+				require.ErrorContains(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestDeviceLibForDriver(t *testing.T) {
+	schema := []api.AttributeSchema{
+		{Name: "model", Type: api.AttributeTypeString},
+		{Name: "features", Type: api.AttributeTypeString},
+		{Name: "numa", Type: api.AttributeTypeInt},
+		{Name: "memory", Type: api.AttributeTypeQuantity},
+		{Name: "firmwareVersion", Type: api.AttributeTypeSemVer},
+	}
+
+	testCases := map[string]struct {
+		constraints string
+		expErr      string
+	}{
+		"hasAttribute": {
+			constraints: "device.hasAttribute('model') && device.hasAttribute('vendor')",
+		},
+		"attributeOr": {
+			constraints: "device.attributeOr('numa', 0) == 1",
+		},
+		"attributeOr wrong type": {
+			constraints: "device.attributeOr('numa', 0) == 'one'",
+			expErr:      "found no matching overload for '_==_' applied to '(int, string)'",
+		},
+		"matchesRegex and matchesGlob": {
+			constraints: "device.model.matchesRegex('^foozer') && device.numa.matchesRegex('1') && device.memory.matchesGlob('*Gi') && device.firmwareVersion.matchesGlob('1.*')",
+		},
+		"csv": {
+			constraints: "'fp16' in device.features.csv() && device.features.csvContains('int8')",
+		},
+		"csvContains int": {
+			constraints: "device.numa.csvContains('1')",
+			expErr:      "found no matching overload for 'csvContains' applied to 'int.(string)'",
+		},
+		"csvContains quantity": {
+			constraints: "device.memory.csvContains('40Gi')",
+			expErr:      "found no matching overload for 'csvContains' applied to 'k8srm.Quantity.(string)'",
+		},
+		"csvContains semver": {
+			constraints: "device.firmwareVersion.csvContains('1.8.2')",
+			expErr:      "found no matching overload for 'csvContains' applied to 'k8srm.SemVer.(string)'",
+		},
+		"quantity compared to int": {
+			constraints: "device.memory > 0",
+		},
+		"int compared to quantity": {
+			constraints: "1 < device.memory && device.numa < quantity('2')",
+		},
+		"quantity equal to int": {
+			constraints: "device.memory == 2",
+			expErr:      "found no matching overload for '_==_' applied to '(k8srm.Quantity, int)'",
+		},
+		"int equal to quantity": {
+			constraints: "2 == device.memory",
+			expErr:      "found no matching overload for '_==_' applied to '(int, k8srm.Quantity)'",
+		},
+		"semver compared to int": {
+			constraints: "device.firmwareVersion > 1",
+			expErr:      "found no matching overload for '_>_' applied to '(k8srm.SemVer, int)'",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			err := CompileConstraintsForDriver(tc.constraints, schema)
+			if tc.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.expErr)
+			}
+		})
+	}
+}
+
+func TestDeviceLibActualCost(t *testing.T) {
+	// Attribute values longer than the limit used for the estimate are
+	// rejected by validation, but if one gets through, the evaluation of
+	// the device library functions is still cut short.
+	attrs := []api.Attribute{
+		{Name: "model", StringValue: ptr(strings.Repeat("x", 100000))},
+	}
+
+	testCases := map[string]string{
+		"matchesRegex": "device.model.matchesRegex('^(foozer|barzer)-[0-9]+$')",
+		"matchesGlob":  "device.model.matchesGlob('foozer-*')",
+		"csv":          "device.model.csv().size() == 1",
+		"csvContains":  "device.model.csvContains('foozer')",
+	}
+
+	for tn, constraints := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			_, err := MeetsConstraints(&constraints, attrs)
+			require.EqualError(t, err, "evaluation exceeded the cost limit of 10000")
+		})
+	}
+}
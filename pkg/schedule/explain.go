@@ -0,0 +1,203 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/interpreter"
+	"github.com/google/cel-go/parser"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+)
+
+// explanations caches the programs used to explain why constraints were not
+// met. These are separate from the ones in programs, since they must record
+// the value of every sub-expression, which makes them slower.
+var explanations = newProgramCache(programCacheSize)
+
+// ExplainDeviceConstraints evaluates the constraints for a device in the same
+// way as MeetsDeviceConstraints, and returns the clauses that were not met.
+// The clauses are the operands of the top-level && operators; an expression
+// without one is a single clause. Every clause is evaluated, so all of the
+// ones that were not met are returned, not just the first. The result is
+// empty if the constraints are met.
+func ExplainDeviceConstraints(constraints *string, pool *api.DevicePool, device *api.Device) ([]ConstraintFailure, error) {
+	return explainDeviceConstraints(constraints, pool, device, nil, nil)
+}
+
+func explainDeviceConstraints(constraints *string, pool *api.DevicePool, device *api.Device, poolUsed, deviceUsed map[string]resource.Quantity) ([]ConstraintFailure, error) {
+	if constraints == nil || *constraints == "" {
+		return nil, nil
+	}
+
+	prog, err := explanations.get(*constraints, compileExplanation)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs, err := deviceInputs(pool, device, poolUsed, deviceUsed)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ConstraintsEvalTimeout)
+	defer cancel()
+
+	// An error from a clause is reported with that clause, but if the
+	// evaluation was cut short, the state is incomplete.
+	_, details, err := prog.ContextEval(ctx, inputs)
+	var cancelled interpreter.EvalCancelledError
+	if details == nil || errors.As(err, &cancelled) {
+		return nil, evalError(err)
+	}
+
+	return prog.(*explainProgram).failures(details.State()), nil
+}
+
+// explainProgram is a program that records the value of every sub-expression,
+// along with the clauses of the expression.
+type explainProgram struct {
+	cel.Program
+	clauses []clause
+}
+
+// clause is one of the operands of the top-level && operators in an
+// expression.
+type clause struct {
+	id   int64
+	text string
+
+	// fields are the expression IDs of the device and pool fields used
+	// by the clause, by name.
+	fields map[string]int64
+}
+
+func compileExplanation(expr string) (cel.Program, error) {
+	env, err := celEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	checked, err := checkExpr(env, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []cel.ProgramOption{cel.EvalOptions(cel.OptExhaustiveEval)}
+	opts = append(opts, costProgramOptions()...)
+	prog, err := env.Program(checked, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	native := checked.NativeRep()
+	var clauses []clause
+	for _, e := range splitAnd(native.Expr()) {
+		text, err := parser.Unparse(e, native.SourceInfo())
+		if err != nil {
+			return nil, err
+		}
+
+		c := clause{id: e.ID(), text: text, fields: make(map[string]int64)}
+		collectFields(ast.NavigateExpr(native, e), c.fields)
+		clauses = append(clauses, c)
+	}
+
+	return &explainProgram{Program: prog, clauses: clauses}, nil
+}
+
+// failures returns the clauses that were false, or that could not be
+// evaluated.
+func (p *explainProgram) failures(state interpreter.EvalState) []ConstraintFailure {
+	var result []ConstraintFailure
+	for _, c := range p.clauses {
+		val, ok := state.Value(c.id)
+		if !ok || val == types.True {
+			continue
+		}
+
+		f := ConstraintFailure{Clause: c.text}
+		if types.IsError(val) {
+			f.Error = val.(*types.Err).Error()
+		}
+
+		for name, id := range c.fields {
+			v, ok := state.Value(id)
+			if !ok || types.IsError(v) {
+				continue
+			}
+
+			if f.Values == nil {
+				f.Values = make(map[string]string)
+			}
+			f.Values[name] = formatValue(v)
+		}
+
+		result = append(result, f)
+	}
+
+	return result
+}
+
+// splitAnd returns the operands of the top-level && operators in the
+// expression.
+func splitAnd(e ast.Expr) []ast.Expr {
+	if e.Kind() != ast.CallKind || e.AsCall().FunctionName() != operators.LogicalAnd {
+		return []ast.Expr{e}
+	}
+
+	var result []ast.Expr
+	for _, arg := range e.AsCall().Args() {
+		result = append(result, splitAnd(arg)...)
+	}
+
+	return result
+}
+
+// collectFields finds the fields of the device and pool variables that are
+// used in the expression, such as device.model or pool.resources.memory.
+func collectFields(e ast.NavigableExpr, fields map[string]int64) {
+	if e.Kind() == ast.SelectKind && !e.AsSelect().IsTestOnly() {
+		if name, ok := fieldName(e); ok {
+			fields[name] = e.ID()
+			return
+		}
+	}
+
+	for _, child := range e.Children() {
+		collectFields(child, fields)
+	}
+}
+
+// fieldName returns the name of a field of the device or pool variable.
+func fieldName(e ast.Expr) (string, bool) {
+	switch e.Kind() {
+	case ast.IdentKind:
+		name := e.AsIdent()
+		return name, name == DeviceVarName || name == PoolVarName
+	case ast.SelectKind:
+		prefix, ok := fieldName(e.AsSelect().Operand())
+		return prefix + "." + e.AsSelect().FieldName(), ok
+	}
+
+	return "", false
+}
+
+func formatValue(v ref.Val) string {
+	switch v := v.(type) {
+	case types.String:
+		return strconv.Quote(string(v))
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	return fmt.Sprint(v.Value())
+}
@@ -0,0 +1,111 @@
+package schedule
+
+import (
+	"testing"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExplainDeviceConstraints(t *testing.T) {
+	pool := &api.DevicePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-00-foozer"},
+		Spec: api.DevicePoolSpec{
+			NodeName: ptr("node-00"),
+			Driver:   "example.com-foozer",
+			Attributes: []api.Attribute{
+				{Name: "model", StringValue: ptr("foozer-1000")},
+				{Name: "firmwareVersion", SemVerValue: ptr(api.SemVer("1.8.2"))},
+			},
+		},
+	}
+	device := &api.Device{
+		Name: "dev-00",
+		Attributes: []api.Attribute{
+			{Name: "numa", IntValue: ptr(1)},
+		},
+		Resources: []api.ResourceCapacity{
+			{Name: "memory", Capacity: resource.MustParse("40Gi")},
+		},
+	}
+
+	testCases := map[string]struct {
+		constraints string
+		expErr      string
+		result      []ConstraintFailure
+	}{
+		"met": {
+			constraints: "device.model == 'foozer-1000' && device.numa == 1",
+		},
+		"one clause": {
+			constraints: "device.model == 'foozer-4000'",
+			result: []ConstraintFailure{{
+				Clause: `device.model == "foozer-4000"`,
+				Values: map[string]string{"device.model": `"foozer-1000"`},
+			}},
+		},
+		"all failed clauses": {
+			constraints: "device.model == 'foozer-4000' && device.numa == 1 && device.firmwareVersion >= semver('1.9.0') && pool.nodeName != 'node-00'",
+			result: []ConstraintFailure{
+				{
+					Clause: `device.model == "foozer-4000"`,
+					Values: map[string]string{"device.model": `"foozer-1000"`},
+				},
+				{
+					Clause: `device.firmwareVersion >= semver("1.9.0")`,
+					Values: map[string]string{"device.firmwareVersion": "1.8.2"},
+				},
+				{
+					Clause: `pool.nodeName != "node-00"`,
+					Values: map[string]string{"pool.nodeName": `"node-00"`},
+				},
+			},
+		},
+		"several fields in a clause": {
+			constraints: "device.numa == 0 || device.model.startsWith('foozer-4')",
+			result: []ConstraintFailure{{
+				Clause: `device.numa == 0 || device.model.startsWith("foozer-4")`,
+				Values: map[string]string{"device.numa": "1", "device.model": `"foozer-1000"`},
+			}},
+		},
+		"resources": {
+			constraints: "device.resources.memory >= quantity('80Gi')",
+			result: []ConstraintFailure{{
+				Clause: `device.resources.memory >= quantity("80Gi")`,
+				Values: map[string]string{"device.resources.memory": "40Gi"},
+			}},
+		},
+		"missing attribute": {
+			constraints: "device.vendor == 'example.com' && device.numa == 0",
+			result: []ConstraintFailure{
+				{
+					Clause: `device.vendor == "example.com"`,
+					Error:  "no such key: vendor",
+				},
+				{
+					Clause: `device.numa == 0`,
+					Values: map[string]string{"device.numa": "1"},
+				},
+			},
+		},
+		"invalid": {
+			constraints: "device.model ==",
+			expErr:      "ERROR: <input>:1:16: Syntax error: mismatched input '<EOF>'",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			result, err := ExplainDeviceConstraints(&tc.constraints, pool, device)
+			if tc.expErr == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.result, result)
+			} else {
+				require.ErrorContains(t, err, tc.expErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+)
+
+// selectMatchingInstances chooses the devices for each instance of a claim,
+// such that all of the devices have the same values for the match attributes.
+// Devices without the attributes cannot be chosen. The devices must also have
+// different values for each of the distinct attributes, as in selectInstances.
+//
+// Choosing the devices for each instance independently would not work, since
+// the values found for the first instance may not be available to the others.
+// Instead, each combination of values found among the candidates is tried in
+// turn, for all of the instances together. The combination with the highest
+// score wins; ties go to the one found first. If no combination satisfies all
+// of the instances, the second returned value is the reason.
+func selectMatchingInstances(instances []instance, matchAttrs, distinctAttrs []string) ([]InstanceResult, string) {
+	var best []InstanceResult
+	bestScore := 0
+	for _, values := range matchValues(instances, matchAttrs) {
+		results := selectInstances(instances, func(c candidate) bool {
+			v, ok := attributeValues(c.attrs, matchAttrs)
+			return ok && equalValues(v, values)
+		}, distinctAttrs)
+
+		dcr := DeviceClaimResult{InstanceResults: results}
+		if score := dcr.Score(); score > bestScore {
+			best, bestScore = results, score
+		}
+	}
+
+	if best != nil {
+		return best, ""
+	}
+
+	names := strings.Join(matchAttrs, ", ")
+	var results []InstanceResult
+	for _, inst := range instances {
+		results = append(results, unmatchedResult(inst, fmt.Sprintf("no devices with the same values for %s as the devices for the other instances", names)))
+	}
+
+	return results, fmt.Sprintf("unable to satisfy all of the instances with the same values for %s", names)
+}
+
+// matchValues returns the distinct values of the match attributes among the
+// candidates for all of the instances, in the order they are first found.
+func matchValues(instances []instance, matchAttrs []string) [][]api.Attribute {
+	var result [][]api.Attribute
+	for _, inst := range instances {
+		for _, o := range inst.options {
+			for _, c := range o.candidates {
+				values, ok := attributeValues(c.attrs, matchAttrs)
+				if !ok {
+					continue
+				}
+
+				found := false
+				for _, r := range result {
+					if equalValues(values, r) {
+						found = true
+						break
+					}
+				}
+
+				if !found {
+					result = append(result, values)
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// unmatchedResult returns the result for an instance that could not be
+// satisfied along with the others, which records the devices that were
+// ignored.
+func unmatchedResult(inst instance, reason string) InstanceResult {
+	var results []InstanceResult
+	for _, o := range inst.options {
+		ir := o.result
+		if ir.FailureReason == "" {
+			ir.FailureReason = reason
+		}
+		results = append(results, ir)
+	}
+
+	if !inst.oneOf {
+		return results[0]
+	}
+
+	return InstanceResult{
+		FailureReason:      fmt.Sprintf("none of the %d alternatives could be satisfied", len(inst.options)),
+		FailedAlternatives: results,
+	}
+}
@@ -0,0 +1,117 @@
+package schedule
+
+import (
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"github.com/google/cel-go/interpreter"
+)
+
+// reversibleOps maps each operator that may have a Quantity or a SemVer on the
+// right of a built-in type to its result for the comparison of the two values.
+//
+// These cannot be bound as overloads of the standard operators. The
+// comparison operators have a single binding that dispatches to the Compare
+// method of the left operand, which for an Int or a String does not accept a
+// Quantity or a SemVer, and the equality operators use the Equal method of
+// the left operand.
+var reversibleOps = map[string]func(cmp int) bool{
+	operators.Less:          func(cmp int) bool { return cmp < 0 },
+	operators.LessEquals:    func(cmp int) bool { return cmp <= 0 },
+	operators.Greater:       func(cmp int) bool { return cmp > 0 },
+	operators.GreaterEquals: func(cmp int) bool { return cmp >= 0 },
+	operators.Equals:        func(cmp int) bool { return cmp == 0 },
+	operators.NotEquals:     func(cmp int) bool { return cmp != 0 },
+}
+
+// decorateReversedOperators replaces each call to one of the reversibleOps
+// with a reversedCall. It is used by each of the libraries that declares such
+// an operator, and only wraps a call once.
+func decorateReversedOperators(i interpreter.Interpretable) (interpreter.Interpretable, error) {
+	if _, ok := i.(*reversedCall); ok {
+		return i, nil
+	}
+
+	call, ok := i.(interpreter.InterpretableCall)
+	if !ok || len(call.Args()) != 2 {
+		return i, nil
+	}
+
+	op, ok := reversibleOps[call.Function()]
+	if !ok {
+		return i, nil
+	}
+
+	return &reversedCall{InterpretableCall: call, op: op}, nil
+}
+
+// reversedCall evaluates an operator with an Int or a String on the left and a
+// Quantity or a SemVer on the right, by comparing the operands the other way
+// around. For other operands, it gives the same result as the standard
+// operator. It is still an InterpretableCall, so that the cost of the call is
+// tracked as before.
+type reversedCall struct {
+	interpreter.InterpretableCall
+	op func(cmp int) bool
+}
+
+// Eval implements interpreter.Interpretable.
+func (c *reversedCall) Eval(vars interpreter.Activation) ref.Val {
+	args := c.Args()
+	lhs := args[0].Eval(vars)
+	rhs := args[1].Eval(vars)
+	if types.IsUnknownOrError(lhs) {
+		return lhs
+	}
+	if types.IsUnknownOrError(rhs) {
+		return rhs
+	}
+
+	if isReversed(lhs, rhs) {
+		return applyOp(rhs.(traits.Comparer).Compare(lhs), func(cmp int) bool { return c.op(-cmp) })
+	}
+
+	switch c.Function() {
+	case operators.Equals:
+		return types.Equal(lhs, rhs)
+	case operators.NotEquals:
+		eq := types.Equal(lhs, rhs)
+		if types.IsError(eq) {
+			return eq
+		}
+		return types.Bool(eq != types.True)
+	}
+
+	comparer, ok := lhs.(traits.Comparer)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(lhs)
+	}
+	return applyOp(comparer.Compare(rhs), c.op)
+}
+
+// applyOp applies an operator to the result of a comparison, unless the
+// comparison failed.
+func applyOp(result ref.Val, op func(cmp int) bool) ref.Val {
+	cmp, ok := result.(types.Int)
+	if !ok {
+		return result
+	}
+	return types.Bool(op(int(cmp)))
+}
+
+// isReversed returns true if the left operand is an Int or a String, and the
+// right is a Quantity or a SemVer, which can be compared to it.
+func isReversed(lhs, rhs ref.Val) bool {
+	switch lhs.(type) {
+	case types.Int:
+		_, ok := rhs.(Quantity)
+		return ok
+	case types.String:
+		switch rhs.(type) {
+		case Quantity, SemVer:
+			return true
+		}
+	}
+	return false
+}
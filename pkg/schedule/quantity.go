@@ -0,0 +1,208 @@
+package schedule
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"gopkg.in/inf.v0"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// QuantityType is the CEL type of a resource.Quantity.
+var QuantityType = cel.ObjectType("k8srm.Quantity",
+	traits.ComparerType,
+	traits.AdderType,
+	traits.SubtractorType,
+	traits.MultiplierType,
+)
+
+// Quantity is the CEL value of a resource.Quantity. It supports the
+// comparison operators, addition and subtraction of other quantities, and
+// multiplication by an int.
+type Quantity struct {
+	resource.Quantity
+}
+
+var (
+	quantityReflectType    = reflect.TypeOf(resource.Quantity{})
+	quantityPtrReflectType = reflect.TypeOf(&resource.Quantity{})
+)
+
+// ConvertToNative implements ref.Val.
+func (q Quantity) ConvertToNative(typeDesc reflect.Type) (any, error) {
+	switch typeDesc {
+	case quantityReflectType:
+		return q.Quantity.DeepCopy(), nil
+	case quantityPtrReflectType:
+		c := q.Quantity.DeepCopy()
+		return &c, nil
+	}
+	return nil, fmt.Errorf("type conversion error from 'Quantity' to '%v'", typeDesc)
+}
+
+// ConvertToType implements ref.Val.
+func (q Quantity) ConvertToType(typeVal ref.Type) ref.Val {
+	switch typeVal {
+	case QuantityType:
+		return q
+	case types.StringType:
+		return types.String(q.Quantity.String())
+	case types.TypeType:
+		return QuantityType
+	}
+	return types.NewErr("type conversion error from '%s' to '%s'", QuantityType, typeVal)
+}
+
+// Equal implements ref.Val. Like Compare, it accepts a Quantity, an Int, or a
+// String, which is parsed as a quantity. Other values are never equal.
+func (q Quantity) Equal(other ref.Val) ref.Val {
+	switch other.(type) {
+	case Quantity, types.Int, types.String:
+		return applyOp(q.Compare(other), func(cmp int) bool { return cmp == 0 })
+	}
+	return types.False
+}
+
+// Type implements ref.Val.
+func (q Quantity) Type() ref.Type {
+	return QuantityType
+}
+
+// Value implements ref.Val.
+func (q Quantity) Value() any {
+	return q.Quantity
+}
+
+// Compare implements traits.Comparer. The other value may be a Quantity, an
+// Int, or a String, which is parsed as a quantity.
+func (q Quantity) Compare(other ref.Val) ref.Val {
+	switch o := other.(type) {
+	case Quantity:
+		return types.Int(q.Quantity.Cmp(o.Quantity))
+	case types.Int:
+		return types.Int(q.Quantity.AsDec().Cmp(inf.NewDec(int64(o), 0)))
+	case types.String:
+		oq, err := resource.ParseQuantity(string(o))
+		if err != nil {
+			return types.NewErr("invalid quantity %q: %v", string(o), err)
+		}
+		return types.Int(q.Quantity.Cmp(oq))
+	}
+	return types.MaybeNoSuchOverloadErr(other)
+}
+
+// Add implements traits.Adder.
+func (q Quantity) Add(other ref.Val) ref.Val {
+	o, ok := other.(Quantity)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(other)
+	}
+	result := q.Quantity.DeepCopy()
+	result.Add(o.Quantity)
+	return Quantity{result}
+}
+
+// Subtract implements traits.Subtractor.
+func (q Quantity) Subtract(other ref.Val) ref.Val {
+	o, ok := other.(Quantity)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(other)
+	}
+	result := q.Quantity.DeepCopy()
+	result.Sub(o.Quantity)
+	return Quantity{result}
+}
+
+// Multiply implements traits.Multiplier.
+func (q Quantity) Multiply(other ref.Val) ref.Val {
+	i, ok := other.(types.Int)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(other)
+	}
+	d := q.Quantity.AsDec()
+	d.Mul(d, inf.NewDec(int64(i), 0))
+	return Quantity{*resource.NewDecimalQuantity(*d, q.Quantity.Format)}
+}
+
+// String returns the canonical form of the quantity, for use in messages.
+func (q Quantity) String() string {
+	return q.Quantity.String()
+}
+
+// QuantityLib makes the Quantity type available in expressions, along with
+// the quantity() function, which parses a quantity from a string; e.g.,
+// device.memory >= quantity('10Gi'). A quantity may also be compared directly
+// to a string on either side, which is parsed in the same way; e.g.,
+// device.memory >= '10Gi' or '10Gi' <= device.memory.
+//
+// When the device attributes are not typed, == and != also compare a quantity
+// and a string by value. When they are typed, such an expression does not
+// compile, since the CEL equality operators only apply to values of the same
+// type.
+func QuantityLib() cel.EnvOption {
+	return cel.Lib(quantityLib{})
+}
+
+type quantityLib struct{}
+
+func (quantityLib) LibraryName() string {
+	return "k8srm.quantity"
+}
+
+func (quantityLib) CompileOptions() []cel.EnvOption {
+	// The operators only need declarations. At runtime, they are
+	// dispatched to the trait implementations of Quantity, with the
+	// operands reversed if need be.
+	binary := func(name, op string, arg, result *cel.Type) cel.EnvOption {
+		return cel.Function(op, cel.Overload(name, []*cel.Type{QuantityType, arg}, result))
+	}
+	reversed := func(name, op string, arg *cel.Type) cel.EnvOption {
+		return cel.Function(op, cel.Overload(name, []*cel.Type{arg, QuantityType}, cel.BoolType))
+	}
+
+	return []cel.EnvOption{
+		cel.Function("quantity",
+			cel.Overload("string_to_quantity", []*cel.Type{cel.StringType}, QuantityType,
+				cel.UnaryBinding(stringToQuantity)),
+		),
+		binary("less_quantity", operators.Less, QuantityType, cel.BoolType),
+		binary("less_equals_quantity", operators.LessEquals, QuantityType, cel.BoolType),
+		binary("greater_quantity", operators.Greater, QuantityType, cel.BoolType),
+		binary("greater_equals_quantity", operators.GreaterEquals, QuantityType, cel.BoolType),
+		binary("less_quantity_string", operators.Less, cel.StringType, cel.BoolType),
+		binary("less_equals_quantity_string", operators.LessEquals, cel.StringType, cel.BoolType),
+		binary("greater_quantity_string", operators.Greater, cel.StringType, cel.BoolType),
+		binary("greater_equals_quantity_string", operators.GreaterEquals, cel.StringType, cel.BoolType),
+		reversed("less_string_quantity", operators.Less, cel.StringType),
+		reversed("less_equals_string_quantity", operators.LessEquals, cel.StringType),
+		reversed("greater_string_quantity", operators.Greater, cel.StringType),
+		reversed("greater_equals_string_quantity", operators.GreaterEquals, cel.StringType),
+		binary("add_quantity", operators.Add, QuantityType, QuantityType),
+		binary("subtract_quantity", operators.Subtract, QuantityType, QuantityType),
+		binary("multiply_quantity_int", operators.Multiply, cel.IntType, QuantityType),
+	}
+}
+
+func (quantityLib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{cel.CustomDecorator(decorateReversedOperators)}
+}
+
+func stringToQuantity(arg ref.Val) ref.Val {
+	s, ok := arg.(types.String)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(arg)
+	}
+
+	q, err := resource.ParseQuantity(string(s))
+	if err != nil {
+		return types.NewErr("invalid quantity %q: %v", string(s), err)
+	}
+
+	return Quantity{q}
+}
@@ -0,0 +1,167 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+)
+
+type NodeResult struct {
+	NodeName           string
+	DeviceClaimResults []DeviceClaimResult
+}
+
+// DeviceClaimResult contains the results of an attempt to satisfy a
+// DeviceClaim against a collection of pools (typically a node)
+type DeviceClaimResult struct {
+	ClaimName       string           `json:"claimName"`
+	AdminAccess     bool             `json:"adminAccess,omitempty"`
+	InstanceResults []InstanceResult `json:"instanceResults"`
+
+	// FailureReason says why the claim could not be satisfied, when that
+	// is not down to any one instance; for example, when the instances
+	// could not be satisfied with the same values for the
+	// MatchAttributes of the claim.
+	FailureReason string `json:"failureReason,omitempty"`
+}
+
+// InstanceResult contains the results of an attempt to satisfy a single
+// DeviceClaimInstance from a DeviceClaimSpec.
+type InstanceResult struct {
+	Allocations []api.DeviceAllocation `json:"allocations,omitempty"`
+	Score       int                    `json:"score"`
+
+	FailureReason string `json:"failureReason,omitempty"`
+
+	// Alternative is the index of the alternative in OneOf that was
+	// chosen. It is nil if the instance has no alternatives, or none of
+	// them could be satisfied.
+	Alternative *int `json:"alternative,omitempty"`
+
+	// FailedAlternatives are the results for the alternatives in OneOf
+	// that were tried before the chosen one, or for all of them if none
+	// could be satisfied.
+	FailedAlternatives []InstanceResult `json:"failedAlternatives,omitempty"`
+
+	IgnoredPools   []PoolResult   `json:"ignoredPools,omitempty"`
+	IgnoredDevices []DeviceResult `json:"ignoredDevices,omitempty"`
+}
+
+// PoolResult records why an entire device pool was not considered
+// for a claim.
+type PoolResult struct {
+	PoolName string `json:"poolName"`
+
+	FailureReason string `json:"failureReason,omitempty"`
+}
+
+// DeviceResult records why a specific device was not considered
+// for a claim.
+type DeviceResult struct {
+	PoolName   string `json:"poolName"`
+	DeviceName string `json:"deviceName"`
+
+	FailureReason string `json:"failureReason,omitempty"`
+
+	// FailedConstraints are the clauses of the constraints that the
+	// device did not meet. They are only recorded in explain mode.
+	FailedConstraints []ConstraintFailure `json:"failedConstraints,omitempty"`
+}
+
+// ConstraintFailure describes a clause of a constraints expression that was
+// false for a device, or that could not be evaluated.
+type ConstraintFailure struct {
+	// Clause is the text of the clause.
+	Clause string `json:"clause"`
+
+	// Values are the values of the device and pool fields used by the
+	// clause, by name; for example, "device.model".
+	Values map[string]string `json:"values,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// NodeResult methods
+
+func (nr *NodeResult) Score() int {
+	// The score for this node is zero if any
+	// claim could not be satisfied, and the average
+	// score for all claims otherwise.
+	if len(nr.DeviceClaimResults) == 0 {
+		return 0
+	}
+
+	sum := 0
+	for _, dcr := range nr.DeviceClaimResults {
+		if dcr.Score() == 0 {
+			return 0
+		}
+
+		sum += dcr.Score()
+	}
+
+	return sum / len(nr.DeviceClaimResults)
+}
+
+func (nr *NodeResult) Allocations() []api.DeviceAllocation {
+	if nr.Score() == 0 {
+		return nil
+	}
+
+	var allocations []api.DeviceAllocation
+	for _, dcr := range nr.DeviceClaimResults {
+		allocations = append(allocations, dcr.Allocations()...)
+	}
+
+	return allocations
+}
+
+func (nr *NodeResult) Summary() string {
+	if nr.Score() > 0 {
+		return fmt.Sprintf("%s: satisfied all claims with score %d", nr.NodeName, nr.Score())
+	}
+
+	var unsatisfied []string
+	for _, dcr := range nr.DeviceClaimResults {
+		if dcr.Score() == 0 {
+			unsatisfied = append(unsatisfied, dcr.ClaimName)
+		}
+	}
+
+	return fmt.Sprintf("%s: could not satisfy these claims: %s", nr.NodeName, strings.Join(unsatisfied, ", "))
+}
+
+// DeviceClaimResult methods
+
+func (dcr *DeviceClaimResult) Score() int {
+	// Like nodes, a claim scores zero if any of its instances could not be
+	// satisfied, and the average of the instance scores otherwise.
+	if len(dcr.InstanceResults) == 0 || dcr.FailureReason != "" {
+		return 0
+	}
+
+	sum := 0
+	for _, ir := range dcr.InstanceResults {
+		if ir.Score == 0 {
+			return 0
+		}
+
+		sum += ir.Score
+	}
+
+	return sum / len(dcr.InstanceResults)
+}
+
+func (dcr *DeviceClaimResult) Allocations() []api.DeviceAllocation {
+	if dcr.Score() == 0 {
+		return nil
+	}
+
+	var allocations []api.DeviceAllocation
+	for _, ir := range dcr.InstanceResults {
+		allocations = append(allocations, ir.Allocations...)
+	}
+
+	return allocations
+}
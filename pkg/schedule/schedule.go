@@ -0,0 +1,865 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"gopkg.in/inf.v0"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// CountResource is the name of the request that all drivers support,
+	// which is the number of devices to allocate.
+	CountResource = api.CountResource
+)
+
+// Cluster contains the cluster-scoped objects that are needed to satisfy
+// claims.
+type Cluster struct {
+	// Drivers are the registered DeviceDrivers, which are used to resolve
+	// claims for a device type.
+	Drivers []api.DeviceDriver
+
+	// Classes are the DeviceClasses that claims may name. A device must
+	// meet the constraints of the class as well as those of the claim. For a
+	// claim with a device type, a device must meet the constraints of one of
+	// the classes for that type, if there are any.
+	Classes []api.DeviceClass
+
+	// Pools are the DevicePools from which devices are allocated.
+	Pools []api.DevicePool
+
+	// Allocations are the existing device allocations for ordinary
+	// claims. These devices are only available to claims with admin
+	// access. Allocations for DevicePrivilegedClaims should not be
+	// included.
+	Allocations []api.DeviceAllocation
+
+	// Explain turns on explain mode, in which the results for devices
+	// that do not meet the constraints say which clauses they failed,
+	// and the values involved. This is slower, since the constraints are
+	// evaluated again for every such device.
+	Explain bool
+}
+
+// claimRequest is the common representation of the different kinds of claims
+// that the scheduler must satisfy.
+type claimRequest struct {
+	name        string
+	spec        api.DeviceClaimSpec
+	adminAccess bool
+}
+
+// SelectNode will select the node that can best satisfy all the claims.
+//
+// Devices in the cluster's existing allocations are not available to ordinary
+// claims. Privileged claims have admin access; they can be satisfied by any
+// device, allocated or not, and do not consume the devices they are given. On
+// each node, the devices allocated to an ordinary claim are not available to
+// the claims after it.
+//
+// The first returned value is an array of the device allocations needed to
+// satisfy all the claims. In the event no node can be selected, this will be
+// empty. The second returned value is an array of the results of evaluating
+// each node.
+func SelectNode(claims []api.DeviceClaim, privilegedClaims []api.DevicePrivilegedClaim, cluster Cluster) ([]api.DeviceAllocation, []NodeResult) {
+	var requests []claimRequest
+	for _, c := range claims {
+		requests = append(requests, claimRequest{name: c.Name, spec: c.Spec})
+	}
+	for _, c := range privilegedClaims {
+		requests = append(requests, claimRequest{name: c.Name, spec: c.Spec, adminAccess: true})
+	}
+
+	state := newAllocationState(cluster.Allocations)
+
+	// Collect the pools by node
+	poolsByNode := make(map[string][]api.DevicePool)
+	for _, p := range cluster.Pools {
+		// ignore pools not associated with a node
+		if p.Spec.NodeName == nil || *p.Spec.NodeName == "" {
+			continue
+		}
+
+		poolsByNode[*p.Spec.NodeName] = append(poolsByNode[*p.Spec.NodeName], p)
+	}
+
+	// Evaluate the nodes in a stable order, so that ties are always broken
+	// the same way.
+	var nodes []string
+	for node := range poolsByNode {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	var results []NodeResult
+	// Evaluate each node against the claims
+	for _, node := range nodes {
+		results = append(results, evaluateNode(node, requests, cluster, state, poolsByNode[node]))
+	}
+
+	best := BestNode(results)
+	if best == nil {
+		return nil, results
+	}
+
+	return best.Allocations(), results
+}
+
+// BestNode returns the result for the node with the highest score, or nil if
+// no node could satisfy all the claims. Ties go to the earliest node in the
+// results.
+func BestNode(results []NodeResult) *NodeResult {
+	var best *NodeResult
+	for i := range results {
+		nr := &results[i]
+		if nr.Score() == 0 {
+			continue
+		}
+
+		if best == nil || nr.Score() > best.Score() {
+			best = nr
+		}
+	}
+
+	return best
+}
+
+func evaluateNode(node string, claims []claimRequest, cluster Cluster, state *allocationState, pools []api.DevicePool) NodeResult {
+	nr := NodeResult{
+		NodeName: node,
+	}
+
+	// Theoretically, when there are multiple claims, the order in which
+	// they are considered may make a difference. In general without
+	// partitioning and without per-device resources, it should be rare
+	// for this to be an issue. The `MatchAttributes` functionality, along
+	// with multiple claims for the similar devices, could conceivably
+	// result in one order being solvable, and another not being solvable.
+	//
+	// Regardless, for the prototype we will not worry about this, and will
+	// just evaluate the claims in the order presented.
+	//
+	// The devices allocated to each claim, and the pool and device
+	// resources they consume, are not available to the claims after it.
+	// They are recorded in a working state for the node, so that other
+	// nodes are not affected.
+	nodeState := state.fork()
+	for _, c := range claims {
+		dcr := evaluateNodeForClaim(c, cluster, nodeState, pools)
+		nr.DeviceClaimResults = append(nr.DeviceClaimResults, dcr)
+
+		// Claims with admin access do not consume their devices.
+		if !c.adminAccess {
+			nodeState.add(dcr.Allocations())
+		}
+	}
+
+	return nr
+}
+
+func evaluateNodeForClaim(claim claimRequest, cluster Cluster, state *allocationState, pools []api.DevicePool) DeviceClaimResult {
+	dcr := DeviceClaimResult{
+		ClaimName:   claim.name,
+		AdminAccess: claim.adminAccess,
+	}
+
+	// The candidates for each instance do not depend on the devices
+	// chosen for the others, so they are found once, up front.
+	var instances []instance
+	for _, ci := range claim.spec.Claims {
+		instances = append(instances, findInstanceOptions(ci, claim.adminAccess, cluster, state, pools))
+	}
+
+	if len(claim.spec.MatchAttributes) > 0 {
+		dcr.InstanceResults, dcr.FailureReason = selectMatchingInstances(instances, claim.spec.MatchAttributes, claim.spec.DistinctAttributes)
+		return dcr
+	}
+
+	dcr.InstanceResults = selectInstances(instances, nil, claim.spec.DistinctAttributes)
+
+	return dcr
+}
+
+// instance holds the ways of satisfying a claim instance: either the instance
+// itself, or the alternatives in its OneOf, in order of priority.
+type instance struct {
+	options []option
+	oneOf   bool
+}
+
+// option is a claim detail, along with the devices that could be used for it.
+type option struct {
+	detail     api.DeviceClaimDetail
+	required   int
+	candidates []candidate
+
+	// deviceRequests is the amount of each per-device resource that is
+	// allocated from each selected device. It is nil for claims with
+	// admin access, which do not consume the devices.
+	deviceRequests map[string]resource.Quantity
+
+	// result holds the pools and devices that were ignored, or the
+	// failure reason if the detail cannot be satisfied at all.
+	result InstanceResult
+}
+
+func findInstanceOptions(ci api.DeviceClaimInstance, adminAccess bool, cluster Cluster, state *allocationState, pools []api.DevicePool) instance {
+	if len(ci.OneOf) == 0 {
+		return instance{options: []option{findCandidates(ci.DeviceClaimDetail, adminAccess, cluster, state, pools)}}
+	}
+
+	inst := instance{oneOf: true}
+	for _, detail := range ci.OneOf {
+		inst.options = append(inst.options, findCandidates(detail, adminAccess, cluster, state, pools))
+	}
+
+	return inst
+}
+
+// selectInstances chooses the devices for each instance in turn. A device
+// chosen for one instance is not available to the later ones, and neither are
+// the devices with the same value as it for any of the distinct attributes.
+// The pool resources requested by the chosen devices are not available to the
+// later instances either. If usable is not nil, only the candidates for which
+// it returns true may be chosen.
+func selectInstances(instances []instance, usable func(candidate) bool, distinctAttrs []string) []InstanceResult {
+	taken := make(map[deviceKey]bool)
+	var picked []candidate
+	var takenValues [][]api.Attribute
+	available := func(c candidate) bool {
+		if taken[c.key()] || (usable != nil && !usable(c)) || !fitsPool(c, picked) {
+			return false
+		}
+		if len(distinctAttrs) == 0 {
+			return true
+		}
+
+		values, ok := attributeValues(c.attrs, distinctAttrs)
+		if !ok {
+			return false
+		}
+		for _, tv := range takenValues {
+			if anyEqualValue(values, tv) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var results []InstanceResult
+	for _, inst := range instances {
+		ir := selectInstance(inst, available, distinctAttrs, picked)
+		picked = append(picked, ir.selected...)
+		for _, c := range ir.selected {
+			taken[c.key()] = true
+			if len(distinctAttrs) > 0 {
+				values, _ := attributeValues(c.attrs, distinctAttrs)
+				takenValues = append(takenValues, values)
+			}
+		}
+		results = append(results, ir.InstanceResult)
+	}
+
+	return results
+}
+
+// selection is the result for a claim instance, along with the candidates
+// that were selected.
+type selection struct {
+	InstanceResult
+	selected []candidate
+}
+
+// selectInstance chooses the devices for a claim instance from the available
+// candidates. The alternatives in OneOf are tried in order of priority, and
+// the first one that can be satisfied is used. Its score is scaled down by its
+// position in the list, so that a node that can satisfy an earlier
+// alternative is preferred: with three alternatives, the scores are 100, 66
+// and 33. The selected devices must have different values for each of the
+// distinct attributes, along with those of the claim detail, and must fit in
+// their pools along with the devices already picked for the claim.
+func selectInstance(inst instance, available func(candidate) bool, distinctAttrs []string, picked []candidate) selection {
+	if !inst.oneOf {
+		return selectOption(inst.options[0], available, distinctAttrs, picked)
+	}
+
+	var failed []InstanceResult
+	for i, o := range inst.options {
+		ir := selectOption(o, available, distinctAttrs, picked)
+		if ir.Score == 0 {
+			failed = append(failed, ir.InstanceResult)
+			continue
+		}
+
+		alternative := i
+		ir.Alternative = &alternative
+		ir.Score = max(ir.Score*(len(inst.options)-i)/len(inst.options), 1)
+		ir.FailedAlternatives = failed
+		return ir
+	}
+
+	return selection{InstanceResult: InstanceResult{
+		FailureReason:      fmt.Sprintf("none of the %d alternatives could be satisfied", len(inst.options)),
+		FailedAlternatives: failed,
+	}}
+}
+
+// selectOption chooses the devices for a claim detail from its available
+// candidates.
+func selectOption(o option, available func(candidate) bool, distinctAttrs []string, picked []candidate) selection {
+	ir := selection{InstanceResult: o.result}
+	if ir.FailureReason != "" {
+		return ir
+	}
+
+	var candidates []candidate
+	for _, c := range o.candidates {
+		if available(c) {
+			candidates = append(candidates, c)
+		}
+	}
+
+	distinctAttrs = append(append([]string(nil), o.detail.DistinctAttributes...), distinctAttrs...)
+	selected := selectDevices(candidates, o.required, o.detail.MatchAttributes, distinctAttrs, picked)
+	if selected == nil {
+		ir.FailureReason = fmt.Sprintf("unable to satisfy request for %d devices from %d candidates", o.required, len(candidates))
+		return ir
+	}
+
+	ir.selected = selected
+	for _, c := range selected {
+		// The candidates have all the requested resources.
+		resources, _ := deviceResourceAllocations(c.device, o.deviceRequests)
+		ir.Allocations = append(ir.Allocations, api.DeviceAllocation{
+			DevicePoolName: c.pool.Name,
+			DeviceName:     c.device.Name,
+			Allocations:    resources,
+		})
+	}
+	ir.Score = 100
+
+	return ir
+}
+
+// candidate is a device that has passed all the filters for a claim, along
+// with the attributes used to evaluate it.
+type candidate struct {
+	pool   *api.DevicePool
+	device *api.Device
+	attrs  []api.Attribute
+
+	// poolFree is the amount of each pool resource that is left for the
+	// claim. It is nil for claims with admin access, which do not
+	// consume the pool resources, and for shared devices, whose pool
+	// resources were consumed when they were first allocated.
+	poolFree map[string]resource.Quantity
+}
+
+func (c candidate) key() deviceKey {
+	return deviceKey{pool: c.pool.Name, device: c.device.Name}
+}
+
+// fitsPool returns true if the pool resources requested by the candidate,
+// along with those requested by the devices already picked from the same
+// pool, are no more than what is left in the pool.
+func fitsPool(c candidate, picked []candidate) bool {
+	if c.poolFree == nil {
+		return true
+	}
+
+	for name, q := range c.device.Requests {
+		needed := q.DeepCopy()
+		for _, p := range picked {
+			if p.pool.Name == c.pool.Name && p.poolFree != nil {
+				if pq, ok := p.device.Requests[name]; ok {
+					needed.Add(pq)
+				}
+			}
+		}
+
+		if free := c.poolFree[name]; needed.Cmp(free) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fitsDevice returns true if the device has enough of each per-device
+// resource left for the requests.
+func fitsDevice(device *api.Device, requests, used map[string]resource.Quantity) bool {
+	allocations, ok := deviceResourceAllocations(device, requests)
+	if !ok {
+		return false
+	}
+
+	free := resourcesFree(device.Resources, used)
+	for _, ra := range allocations {
+		if q := free[ra.Name]; ra.Allocation.Cmp(q) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// deviceResourceAllocations returns the amount of each per-device resource
+// that is allocated from the device for the requests, rounded up to the block
+// size of the resource. It returns false if the device does not have one of
+// the resources.
+func deviceResourceAllocations(device *api.Device, requests map[string]resource.Quantity) ([]api.ResourceAllocation, bool) {
+	var allocations []api.ResourceAllocation
+	for _, r := range device.Resources {
+		if q, ok := requests[r.Name]; ok {
+			allocations = append(allocations, api.ResourceAllocation{
+				Name:       r.Name,
+				Allocation: roundUp(q, r.BlockSize),
+			})
+		}
+	}
+
+	return allocations, len(allocations) == len(requests)
+}
+
+// roundUp returns the smallest multiple of the block size that is no less
+// than the quantity. A missing block size is the same as one.
+func roundUp(q resource.Quantity, blockSize *resource.Quantity) resource.Quantity {
+	if blockSize == nil || blockSize.Sign() <= 0 {
+		return q.DeepCopy()
+	}
+
+	block := blockSize.DeepCopy()
+	blocks := new(inf.Dec).QuoRound(q.AsDec(), block.AsDec(), 0, inf.RoundCeil)
+	return *resource.NewDecimalQuantity(*blocks.Mul(blocks, block.AsDec()), q.Format)
+}
+
+// findCandidates finds the devices in the specified pools that could be used
+// for the claim detail. Devices are considered one at a time, in the order
+// they appear in the pools. With admin access, devices that are already
+// allocated may be used, and the pool resources they request are ignored.
+//
+// If the claim requests per-device resources, each of the devices must have
+// enough of them left, and they are allocated from the device rather than
+// the whole device, so that it may be shared with other claims that do the
+// same.
+func findCandidates(detail api.DeviceClaimDetail, adminAccess bool, cluster Cluster, state *allocationState, pools []api.DevicePool) option {
+	o := option{detail: detail}
+	ir := &o.result
+
+	required, err := requestedCount(detail)
+	if err != nil {
+		ir.FailureReason = err.Error()
+		return o
+	}
+	o.required = required
+	if !adminAccess {
+		o.deviceRequests = deviceRequests(detail)
+	}
+
+	// The device must meet the constraints of the class, if any, as well
+	// as those of the claim.
+	var class *api.DeviceClass
+	var constraints []namedConstraints
+	if detail.DeviceClass != nil {
+		class = findClass(cluster.Classes, *detail.DeviceClass)
+		if class == nil {
+			ir.FailureReason = fmt.Sprintf("device class %q not found", *detail.DeviceClass)
+			return o
+		}
+		constraints = append(constraints, namedConstraints{
+			source:      fmt.Sprintf("device class %q", class.Name),
+			constraints: class.Spec.Constraints,
+		})
+	}
+	constraints = append(constraints, namedConstraints{source: "claim", constraints: detail.Constraints})
+
+	// If the claim is for a device type, only pools from drivers that
+	// provide that type may be used. If there are classes for the type,
+	// each device must also be served by one of them.
+	var drivers map[string]bool
+	var typeClasses []api.DeviceClass
+	if detail.DeviceType != nil {
+		drivers = make(map[string]bool)
+		var typeDrivers []api.DeviceDriver
+		typeDrivers, typeClasses = api.ResolveDeviceType(*detail.DeviceType, cluster.Drivers, cluster.Classes)
+		for _, d := range typeDrivers {
+			drivers[d.Name] = true
+		}
+
+		if len(drivers) == 0 {
+			ir.FailureReason = fmt.Sprintf("no driver provides device type %q", *detail.DeviceType)
+			return o
+		}
+	}
+
+	// Eliminate any pools from the wrong drivers, and any devices
+	// that do not meet the constraints.
+	for pi := range pools {
+		p := &pools[pi]
+		if drivers != nil && !drivers[p.Spec.Driver] {
+			ir.IgnoredPools = append(ir.IgnoredPools, PoolResult{
+				PoolName:      p.Name,
+				FailureReason: fmt.Sprintf("driver %q does not provide device type %q", p.Spec.Driver, *detail.DeviceType),
+			})
+			continue
+		}
+
+		if class != nil && class.Spec.Driver != "" && p.Spec.Driver != class.Spec.Driver {
+			ir.IgnoredPools = append(ir.IgnoredPools, PoolResult{
+				PoolName:      p.Name,
+				FailureReason: fmt.Sprintf("driver %q does not match driver %q of device class %q", p.Spec.Driver, class.Spec.Driver, class.Name),
+			})
+			continue
+		}
+
+		var poolClasses []api.DeviceClass
+		if len(typeClasses) > 0 {
+			poolClasses = classesForDriver(typeClasses, p.Spec.Driver)
+			if len(poolClasses) == 0 {
+				ir.IgnoredPools = append(ir.IgnoredPools, PoolResult{
+					PoolName:      p.Name,
+					FailureReason: fmt.Sprintf("no device class for device type %q serves driver %q", *detail.DeviceType, p.Spec.Driver),
+				})
+				continue
+			}
+		}
+
+		poolUsed := state.poolUsage(p)
+		var free map[string]resource.Quantity
+		if !adminAccess {
+			free = poolFree(p, poolUsed)
+		}
+		for di := range p.Spec.Devices {
+			d := &p.Spec.Devices[di]
+
+			if !adminAccess && state.isAllocated(p.Name, d.Name) {
+				if o.deviceRequests == nil || state.isAllocatedWhole(p.Name, d.Name) {
+					ir.IgnoredDevices = append(ir.IgnoredDevices, DeviceResult{
+						PoolName:      p.Name,
+						DeviceName:    d.Name,
+						FailureReason: "device is already allocated",
+					})
+					continue
+				}
+			}
+
+			c := candidate{pool: p, device: d, attrs: p.Spec.DeviceAttributes(d), poolFree: free}
+			if state.isAllocated(p.Name, d.Name) {
+				// The device is shared, or the claim has admin
+				// access.
+				c.poolFree = nil
+			}
+			if !fitsPool(c, nil) {
+				ir.IgnoredDevices = append(ir.IgnoredDevices, DeviceResult{
+					PoolName:      p.Name,
+					DeviceName:    d.Name,
+					FailureReason: "not enough pool resources left for the device",
+				})
+				continue
+			}
+
+			deviceUsed := state.deviceUsage(p.Name, d.Name)
+			if !fitsDevice(d, o.deviceRequests, deviceUsed) {
+				ir.IgnoredDevices = append(ir.IgnoredDevices, DeviceResult{
+					PoolName:      p.Name,
+					DeviceName:    d.Name,
+					FailureReason: "not enough device resources left for the claim",
+				})
+				continue
+			}
+
+			if dr := checkDeviceConstraints(constraints, p, d, poolUsed, deviceUsed, cluster.Explain); dr != nil {
+				ir.IgnoredDevices = append(ir.IgnoredDevices, *dr)
+				continue
+			}
+
+			if len(poolClasses) > 0 && !meetsAnyClassConstraints(poolClasses, p, d, poolUsed, deviceUsed) {
+				ir.IgnoredDevices = append(ir.IgnoredDevices, DeviceResult{
+					PoolName:      p.Name,
+					DeviceName:    d.Name,
+					FailureReason: fmt.Sprintf("constraints of the device classes for device type %q not met", *detail.DeviceType),
+				})
+				continue
+			}
+
+			o.candidates = append(o.candidates, c)
+		}
+	}
+
+	return o
+}
+
+// namedConstraints is a constraints expression, along with a description of
+// where it came from, for reporting.
+type namedConstraints struct {
+	source      string
+	constraints *string
+}
+
+// checkDeviceConstraints evaluates the constraints for a device, in order. It
+// returns nil if the device meets all of them, and otherwise the result for
+// the first one that rejected the device.
+func checkDeviceConstraints(constraints []namedConstraints, pool *api.DevicePool, device *api.Device, poolUsed, deviceUsed map[string]resource.Quantity, explain bool) *DeviceResult {
+	for _, nc := range constraints {
+		meets, err := meetsDeviceConstraints(nc.constraints, pool, device, poolUsed, deviceUsed)
+		if err == nil && meets {
+			continue
+		}
+
+		dr := &DeviceResult{
+			PoolName:      pool.Name,
+			DeviceName:    device.Name,
+			FailureReason: fmt.Sprintf("%s constraints not met", nc.source),
+		}
+		if err != nil {
+			dr.FailureReason = fmt.Sprintf("error evaluating %s constraints: %s", nc.source, err.Error())
+		}
+		if explain {
+			// Any error is the same as the one above.
+			dr.FailedConstraints, _ = explainDeviceConstraints(nc.constraints, pool, device, poolUsed, deviceUsed)
+		}
+		return dr
+	}
+
+	return nil
+}
+
+// classesForDriver returns the classes that do not name a driver, or that name
+// the given one.
+func classesForDriver(classes []api.DeviceClass, driver string) []api.DeviceClass {
+	var result []api.DeviceClass
+	for _, c := range classes {
+		if c.Spec.Driver == "" || c.Spec.Driver == driver {
+			result = append(result, c)
+		}
+	}
+
+	return result
+}
+
+// meetsAnyClassConstraints returns true if the device meets the constraints of
+// any of the classes. Errors evaluating the constraints of a class count as
+// not meeting them.
+func meetsAnyClassConstraints(classes []api.DeviceClass, pool *api.DevicePool, device *api.Device, poolUsed, deviceUsed map[string]resource.Quantity) bool {
+	for _, c := range classes {
+		if meets, err := meetsDeviceConstraints(c.Spec.Constraints, pool, device, poolUsed, deviceUsed); err == nil && meets {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findClass returns the class with the given name, or nil if there is none.
+func findClass(classes []api.DeviceClass, name string) *api.DeviceClass {
+	for i := range classes {
+		if classes[i].Name == name {
+			return &classes[i]
+		}
+	}
+
+	return nil
+}
+
+// requestedCount returns the number of devices requested by the claim, which
+// defaults to one.
+func requestedCount(detail api.DeviceClaimDetail) (int, error) {
+	q, ok := detail.Requests[CountResource]
+	if !ok {
+		return 1, nil
+	}
+
+	count, ok := q.AsInt64()
+	if !ok || count < 1 {
+		return 0, fmt.Errorf("invalid device count %q", q.String())
+	}
+
+	return int(count), nil
+}
+
+// deviceRequests returns the requests of the claim detail other than the
+// count, which are for per-device resources, or nil if there are none.
+func deviceRequests(detail api.DeviceClaimDetail) map[string]resource.Quantity {
+	var requests map[string]resource.Quantity
+	for name, q := range detail.Requests {
+		if name == CountResource {
+			continue
+		}
+		if requests == nil {
+			requests = make(map[string]resource.Quantity)
+		}
+		requests[name] = q
+	}
+
+	return requests
+}
+
+// selectDevices chooses the required number of devices from the candidates. If
+// matchAttrs is not empty, all of the selected devices must have the same
+// value for each of those attributes. If distinctAttrs is not empty, each of
+// the selected devices must have a different value for each of those
+// attributes. Devices without the attributes cannot be selected. The selected
+// devices must fit in their pools along with the devices already picked.
+// Returns nil if the request cannot be satisfied.
+func selectDevices(candidates []candidate, required int, matchAttrs, distinctAttrs []string, picked []candidate) []candidate {
+	if len(matchAttrs) == 0 {
+		return selectDistinct(candidates, required, distinctAttrs, picked)
+	}
+
+	// Group the candidates by their values for the match attributes. The
+	// first group that can satisfy the request wins.
+	var groups [][]candidate
+	for _, c := range candidates {
+		values, ok := attributeValues(c.attrs, matchAttrs)
+		if !ok {
+			continue
+		}
+
+		found := false
+		for gi, g := range groups {
+			groupValues, _ := attributeValues(g[0].attrs, matchAttrs)
+			if equalValues(values, groupValues) {
+				groups[gi] = append(g, c)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			groups = append(groups, []candidate{c})
+		}
+	}
+
+	for _, g := range groups {
+		if selected := selectDistinct(g, required, distinctAttrs, picked); selected != nil {
+			return selected
+		}
+	}
+
+	return nil
+}
+
+// maxDistinctSearchSteps limits the search for distinct devices, which may
+// need to backtrack when there is more than one distinct attribute, or when
+// the devices request pool resources.
+const maxDistinctSearchSteps = 10000
+
+// selectDistinct chooses the required number of candidates, each of which has
+// a different value for each of the distinct attributes. Candidates without
+// the attributes cannot be chosen. The chosen candidates must fit in their
+// pools along with the devices already picked. The earliest candidates are
+// preferred. Returns nil if the request cannot be satisfied.
+func selectDistinct(candidates []candidate, required int, distinctAttrs []string, picked []candidate) []candidate {
+	var usable []candidate
+	var values [][]api.Attribute
+	for _, c := range candidates {
+		if v, ok := attributeValues(c.attrs, distinctAttrs); ok {
+			usable = append(usable, c)
+			values = append(values, v)
+		}
+	}
+
+	// With a single distinct attribute, the first candidate for each
+	// value is always a valid choice, so this never backtracks. With
+	// more than one, or when a device uses up the pool resources that
+	// the others need, a choice may rule out too many of the others.
+	var chosen []int
+	taken := append([]candidate(nil), picked...)
+	steps := 0
+	var search func(start int) bool
+	search = func(start int) bool {
+		if len(chosen) == required {
+			return true
+		}
+
+		for i := start; len(usable)-i >= required-len(chosen); i++ {
+			if steps++; steps > maxDistinctSearchSteps {
+				return false
+			}
+
+			conflict := false
+			for _, j := range chosen {
+				if anyEqualValue(values[i], values[j]) {
+					conflict = true
+					break
+				}
+			}
+			if conflict || !fitsPool(usable[i], taken) {
+				continue
+			}
+
+			chosen = append(chosen, i)
+			taken = append(taken, usable[i])
+			if search(i + 1) {
+				return true
+			}
+			chosen = chosen[:len(chosen)-1]
+			taken = taken[:len(taken)-1]
+		}
+
+		return false
+	}
+
+	if !search(0) {
+		return nil
+	}
+
+	var selected []candidate
+	for _, i := range chosen {
+		selected = append(selected, usable[i])
+	}
+
+	return selected
+}
+
+// attributeValues returns the attributes with the given names, in the same
+// order as the names. The second return value is false if any of them is
+// missing. If an attribute appears more than once, the last one wins.
+func attributeValues(attrs []api.Attribute, names []string) ([]api.Attribute, bool) {
+	result := make([]api.Attribute, len(names))
+	for i, name := range names {
+		found := false
+		for _, a := range attrs {
+			if a.Name == name {
+				result[i] = a
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, false
+		}
+	}
+
+	return result, true
+}
+
+func equalValues(a, b []api.Attribute) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !a[i].EqualValue(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// anyEqualValue returns true if any of the attributes in a has the same value
+// as the one in the same position in b.
+func anyEqualValue(a, b []api.Attribute) bool {
+	for i := range a {
+		if i < len(b) && a[i].EqualValue(b[i]) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,1251 @@
+package schedule
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"github.com/johnbelamaric/k8srm-prototype/pkg/gen"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/yaml"
+)
+
+func claimWithDetails(name string, details ...api.DeviceClaimDetail) api.DeviceClaim {
+	claim := api.DeviceClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+	}
+
+	for _, d := range details {
+		claim.Spec.Claims = append(claim.Spec.Claims, api.DeviceClaimInstance{DeviceClaimDetail: d})
+	}
+
+	return claim
+}
+
+func count(n string) map[string]resource.Quantity {
+	return map[string]resource.Quantity{CountResource: resource.MustParse(n)}
+}
+
+func claimWithOneOf(name string, details ...api.DeviceClaimDetail) api.DeviceClaim {
+	claim := claimWithDetails(name)
+	claim.Spec.Claims = []api.DeviceClaimInstance{{OneOf: details}}
+	return claim
+}
+
+func privilegedClaimWithDetails(name string, details ...api.DeviceClaimDetail) api.DevicePrivilegedClaim {
+	claim := claimWithDetails(name, details...)
+	return api.DevicePrivilegedClaim{
+		ObjectMeta: claim.ObjectMeta,
+		Spec:       claim.Spec,
+	}
+}
+
+func allocations(pool string, devices ...string) []api.DeviceAllocation {
+	var result []api.DeviceAllocation
+	for _, d := range devices {
+		result = append(result, api.DeviceAllocation{DevicePoolName: pool, DeviceName: d})
+	}
+	return result
+}
+
+func driver(name string, deviceTypes ...string) api.DeviceDriver {
+	return api.DeviceDriver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: api.DeviceDriverSpec{
+			DeviceTypes: deviceTypes,
+		},
+	}
+}
+
+func class(name, driver string, constraints *string) api.DeviceClass {
+	return api.DeviceClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: api.DeviceClassSpec{
+			Driver:      driver,
+			DeviceType:  "gpu",
+			Constraints: constraints,
+		},
+	}
+}
+
+// partitionedPool returns a pool for a device with 80Gi of memory, which can
+// be allocated whole, or as two halves.
+func partitionedPool() api.DevicePool {
+	memory := func(q string) map[string]resource.Quantity {
+		return map[string]resource.Quantity{"memory": resource.MustParse(q)}
+	}
+
+	return api.DevicePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-00-foozer"},
+		Spec: api.DevicePoolSpec{
+			NodeName:  ptr("node-00"),
+			Driver:    "example.com-foozer",
+			Resources: []api.ResourceCapacity{{Name: "memory", Capacity: resource.MustParse("80Gi")}},
+			Devices: []api.Device{
+				{Name: "whole", Requests: memory("80Gi")},
+				{Name: "half-0", Requests: memory("40Gi"), Resources: []api.ResourceCapacity{{Name: "memory", Capacity: resource.MustParse("40Gi")}}},
+				{Name: "half-1", Requests: memory("40Gi"), Resources: []api.ResourceCapacity{{Name: "memory", Capacity: resource.MustParse("40Gi")}}},
+			},
+		},
+	}
+}
+
+// gpuAndNICPools returns pools of GPUs and NICs on a node, where only some
+// pairs of a GPU and a NIC share the same NUMA node and PCIe root complex.
+func gpuAndNICPools() []api.DevicePool {
+	device := func(name string, numa int, pcieRoot string) api.Device {
+		return api.Device{
+			Name: name,
+			Attributes: []api.Attribute{
+				{Name: "numa", IntValue: ptr(numa)},
+				{Name: "pcie-root", StringValue: ptr(pcieRoot)},
+			},
+		}
+	}
+
+	return []api.DevicePool{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-00-foozer"},
+			Spec: api.DevicePoolSpec{
+				NodeName: ptr("node-00"),
+				Driver:   "example.com-foozer",
+				Devices: []api.Device{
+					device("gpu-0", 0, "pci-0"),
+					device("gpu-1", 0, "pci-1"),
+					device("gpu-2", 1, "pci-2"),
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-00-nic"},
+			Spec: api.DevicePoolSpec{
+				NodeName: ptr("node-00"),
+				Driver:   "example.com-nic",
+				Devices: []api.Device{
+					device("nic-0", 1, "pci-0"),
+					device("nic-1", 0, "pci-1"),
+					device("nic-2", 1, "pci-2"),
+				},
+			},
+		},
+	}
+}
+
+func TestSelectNode(t *testing.T) {
+	mixedPools := append(gen.Gen("foozer-1000-small", 2), gen.Gen("foozer-4000-small", 2)...)
+	drivers := []api.DeviceDriver{
+		driver("example.com-foozer", "gpu"),
+		driver("example.com-barzer", "gpu", "sriov-nic"),
+		driver("sriov-nic", "sriov-nic"),
+	}
+	classes := []api.DeviceClass{
+		class("foozer", "example.com-foozer", nil),
+		class("foozer-4000", "example.com-foozer", ptr("device.model == 'foozer-4000'")),
+		class("barzer", "example.com-barzer", nil),
+	}
+	testCases := map[string]struct {
+		claims           []api.DeviceClaim
+		privilegedClaims []api.DevicePrivilegedClaim
+		pools            []api.DevicePool
+		allocations      []api.DeviceAllocation
+		expectSuccess    bool
+		expectNode       string
+		expectDeviceSize int
+		expectDevices    []string
+	}{
+		"single device": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{}),
+			},
+			pools:            mixedPools,
+			expectSuccess:    true,
+			expectNode:       "foozer-1000-small-00",
+			expectDeviceSize: 1,
+		},
+		"single with constraint met": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					Constraints: ptr("device.model == 'foozer-4000'"),
+				}),
+			},
+			pools:            mixedPools,
+			expectSuccess:    true,
+			expectNode:       "foozer-4000-small-00",
+			expectDeviceSize: 1,
+		},
+		"single with pool constraint met": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					Constraints: ptr("pool.nodeName == 'foozer-1000-small-01' && device.driver == 'example.com-foozer'"),
+				}),
+			},
+			pools:            mixedPools,
+			expectSuccess:    true,
+			expectNode:       "foozer-1000-small-01",
+			expectDeviceSize: 1,
+		},
+		"single with constraint not met": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					Constraints: ptr("device.model == 'foozer-8000'"),
+				}),
+			},
+			pools:         mixedPools,
+			expectSuccess: false,
+		},
+		"multiple from a single pool": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					Requests: count("4"),
+				}),
+			},
+			pools:            gen.Gen("foozer-1000-small", 2),
+			expectSuccess:    true,
+			expectNode:       "foozer-1000-small-00",
+			expectDeviceSize: 4,
+		},
+		"more devices than any node has": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					Requests: count("5"),
+				}),
+			},
+			pools:         gen.Gen("foozer-1000-small", 2),
+			expectSuccess: false,
+		},
+		"two instances in one claim": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim",
+					api.DeviceClaimDetail{
+						Constraints: ptr("device.numa == '0'"),
+					},
+					api.DeviceClaimDetail{
+						Constraints: ptr("device.numa == '1'"),
+					},
+				),
+			},
+			pools:            gen.Gen("foozer-1000-medium", 1),
+			expectSuccess:    true,
+			expectNode:       "foozer-1000-medium-00",
+			expectDeviceSize: 2,
+		},
+		"claim met with NUMA MatchAttribute": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					Requests:        count("4"),
+					MatchAttributes: []string{"numa"},
+				}),
+			},
+			pools:            gen.Gen("foozer-1000-medium", 2),
+			expectSuccess:    true,
+			expectNode:       "foozer-1000-medium-00",
+			expectDeviceSize: 4,
+		},
+		"claim cannot be met due to NUMA MatchAttribute": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					Requests:        count("5"),
+					MatchAttributes: []string{"numa"},
+				}),
+			},
+			pools:         gen.Gen("foozer-1000-medium", 2),
+			expectSuccess: false,
+		},
+		"single by device type": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					DeviceType: ptr("gpu"),
+				}),
+			},
+			pools:            mixedPools,
+			expectSuccess:    true,
+			expectNode:       "foozer-1000-small-00",
+			expectDeviceSize: 1,
+		},
+		"device type not provided by pool drivers": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					DeviceType: ptr("sriov-nic"),
+				}),
+			},
+			pools:         mixedPools,
+			expectSuccess: false,
+		},
+		"device type with no drivers": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					DeviceType: ptr("vlan"),
+				}),
+			},
+			pools:         mixedPools,
+			expectSuccess: false,
+		},
+		"single by device class": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					DeviceClass: ptr("foozer"),
+				}),
+			},
+			pools:            mixedPools,
+			expectSuccess:    true,
+			expectNode:       "foozer-1000-small-00",
+			expectDeviceSize: 1,
+		},
+		"class constraints met": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					DeviceClass: ptr("foozer-4000"),
+				}),
+			},
+			pools:            mixedPools,
+			expectSuccess:    true,
+			expectNode:       "foozer-4000-small-00",
+			expectDeviceSize: 1,
+		},
+		"class and claim constraints both met": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					DeviceClass: ptr("foozer-4000"),
+					Constraints: ptr("pool.nodeName == 'foozer-4000-small-01'"),
+				}),
+			},
+			pools:            mixedPools,
+			expectSuccess:    true,
+			expectNode:       "foozer-4000-small-01",
+			expectDeviceSize: 1,
+		},
+		"claim constraints met but class constraints not met": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					DeviceClass: ptr("foozer-4000"),
+					Constraints: ptr("device.model == 'foozer-1000'"),
+				}),
+			},
+			pools:         mixedPools,
+			expectSuccess: false,
+		},
+		"class driver does not match pools": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					DeviceClass: ptr("barzer"),
+				}),
+			},
+			pools:         mixedPools,
+			expectSuccess: false,
+		},
+		"class not found": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					DeviceClass: ptr("foozer-8000"),
+				}),
+			},
+			pools:         mixedPools,
+			expectSuccess: false,
+		},
+		"one of with first alternative met": {
+			claims: []api.DeviceClaim{
+				claimWithOneOf("myclaim",
+					api.DeviceClaimDetail{Constraints: ptr("device.model == 'foozer-4000'")},
+					api.DeviceClaimDetail{Constraints: ptr("device.model == 'foozer-1000'")},
+				),
+			},
+			pools:            mixedPools,
+			expectSuccess:    true,
+			expectNode:       "foozer-4000-small-00",
+			expectDeviceSize: 1,
+		},
+		"one of prefers node with earlier alternative": {
+			claims: []api.DeviceClaim{
+				claimWithOneOf("myclaim",
+					api.DeviceClaimDetail{Constraints: ptr("device.model == 'foozer-8000'")},
+					api.DeviceClaimDetail{Constraints: ptr("device.model == 'foozer-1000'"), Requests: count("8")},
+					api.DeviceClaimDetail{Constraints: ptr("device.model == 'foozer-4000'")},
+					api.DeviceClaimDetail{Constraints: ptr("device.model == 'foozer-1000'")},
+				),
+			},
+			pools:            mixedPools,
+			expectSuccess:    true,
+			expectNode:       "foozer-4000-small-00",
+			expectDeviceSize: 1,
+		},
+		"one of with no alternative met": {
+			claims: []api.DeviceClaim{
+				claimWithOneOf("myclaim",
+					api.DeviceClaimDetail{Constraints: ptr("device.model == 'foozer-8000'")},
+					api.DeviceClaimDetail{Requests: count("5")},
+				),
+			},
+			pools:         mixedPools,
+			expectSuccess: false,
+		},
+		"allocated devices are skipped": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{}),
+			},
+			pools:            gen.Gen("foozer-1000-small", 1),
+			allocations:      allocations("foozer-1000-small-00-foozer", "dev-00", "dev-01", "dev-02"),
+			expectSuccess:    true,
+			expectNode:       "foozer-1000-small-00",
+			expectDeviceSize: 1,
+			expectDevices:    []string{"dev-03"},
+		},
+		"all devices allocated": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{}),
+			},
+			pools:         gen.Gen("foozer-1000-small", 1),
+			allocations:   allocations("foozer-1000-small-00-foozer", "dev-00", "dev-01", "dev-02", "dev-03"),
+			expectSuccess: false,
+		},
+		"privileged claim uses allocated devices": {
+			privilegedClaims: []api.DevicePrivilegedClaim{
+				privilegedClaimWithDetails("monitor", api.DeviceClaimDetail{
+					Requests: count("4"),
+				}),
+			},
+			pools:            gen.Gen("foozer-1000-small", 1),
+			allocations:      allocations("foozer-1000-small-00-foozer", "dev-00", "dev-01", "dev-02", "dev-03"),
+			expectSuccess:    true,
+			expectNode:       "foozer-1000-small-00",
+			expectDeviceSize: 4,
+			expectDevices:    []string{"dev-00", "dev-01", "dev-02", "dev-03"},
+		},
+		"privileged claim with an ordinary claim": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{}),
+			},
+			privilegedClaims: []api.DevicePrivilegedClaim{
+				privilegedClaimWithDetails("monitor", api.DeviceClaimDetail{}),
+			},
+			pools:            gen.Gen("foozer-1000-small", 1),
+			allocations:      allocations("foozer-1000-small-00-foozer", "dev-00"),
+			expectSuccess:    true,
+			expectNode:       "foozer-1000-small-00",
+			expectDeviceSize: 2,
+			expectDevices:    []string{"dev-01", "dev-00"},
+		},
+		"pool resources": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					Constraints: ptr("pool.resources.memory == quantity('80Gi')"),
+				}),
+			},
+			pools:            []api.DevicePool{partitionedPool()},
+			expectSuccess:    true,
+			expectNode:       "node-00",
+			expectDeviceSize: 1,
+			expectDevices:    []string{"whole"},
+		},
+		"pool resources used by allocated devices": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					Constraints: ptr("pool.resources.memory >= quantity('40Gi') && pool.capacity.memory == quantity('80Gi')"),
+				}),
+			},
+			pools:            []api.DevicePool{partitionedPool()},
+			allocations:      allocations("node-00-foozer", "half-0"),
+			expectSuccess:    true,
+			expectNode:       "node-00",
+			expectDeviceSize: 1,
+			expectDevices:    []string{"half-1"},
+		},
+		"pool resources exhausted": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					Constraints: ptr("pool.resources.memory >= quantity('40Gi')"),
+				}),
+			},
+			pools:         []api.DevicePool{partitionedPool()},
+			allocations:   allocations("node-00-foozer", "half-0", "half-1"),
+			expectSuccess: false,
+		},
+		"device resources used by allocations": {
+			privilegedClaims: []api.DevicePrivilegedClaim{
+				privilegedClaimWithDetails("monitor", api.DeviceClaimDetail{
+					Constraints: ptr("'memory' in device.resources && device.resources.memory < device.capacity.memory"),
+				}),
+			},
+			pools: []api.DevicePool{partitionedPool()},
+			allocations: []api.DeviceAllocation{{
+				DevicePoolName: "node-00-foozer",
+				DeviceName:     "half-1",
+				Allocations:    []api.ResourceAllocation{{Name: "memory", Allocation: resource.MustParse("10Gi")}},
+			}},
+			expectSuccess:    true,
+			expectNode:       "node-00",
+			expectDeviceSize: 1,
+			expectDevices:    []string{"half-1"},
+		},
+		"two claims compete for the last device": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("first", api.DeviceClaimDetail{}),
+				claimWithDetails("second", api.DeviceClaimDetail{}),
+			},
+			pools:         gen.Gen("foozer-1000-small", 1),
+			allocations:   allocations("foozer-1000-small-00-foozer", "dev-00", "dev-01", "dev-02"),
+			expectSuccess: false,
+		},
+		"two claims compete for the last device on one node": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("first", api.DeviceClaimDetail{}),
+				claimWithDetails("second", api.DeviceClaimDetail{}),
+			},
+			pools:            gen.Gen("foozer-1000-small", 2),
+			allocations:      allocations("foozer-1000-small-00-foozer", "dev-00", "dev-01", "dev-02"),
+			expectSuccess:    true,
+			expectNode:       "foozer-1000-small-01",
+			expectDeviceSize: 2,
+			expectDevices:    []string{"dev-00", "dev-01"},
+		},
+		"claims share a node without sharing devices": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("first", api.DeviceClaimDetail{Requests: count("2")}),
+				claimWithDetails("second", api.DeviceClaimDetail{Requests: count("2")}),
+			},
+			pools:            gen.Gen("foozer-1000-small", 1),
+			expectSuccess:    true,
+			expectNode:       "foozer-1000-small-00",
+			expectDeviceSize: 4,
+			expectDevices:    []string{"dev-00", "dev-01", "dev-02", "dev-03"},
+		},
+		"privileged claim uses the last device after an ordinary claim": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{}),
+			},
+			privilegedClaims: []api.DevicePrivilegedClaim{
+				privilegedClaimWithDetails("monitor", api.DeviceClaimDetail{
+					Requests: count("4"),
+				}),
+			},
+			pools:            gen.Gen("foozer-1000-small", 1),
+			allocations:      allocations("foozer-1000-small-00-foozer", "dev-00", "dev-01", "dev-02"),
+			expectSuccess:    true,
+			expectNode:       "foozer-1000-small-00",
+			expectDeviceSize: 5,
+			expectDevices:    []string{"dev-03", "dev-00", "dev-01", "dev-02", "dev-03"},
+		},
+		"pool resources consumed by an earlier claim": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("first", api.DeviceClaimDetail{
+					Constraints: ptr("device.capacity.memory == quantity('40Gi')"),
+				}),
+				claimWithDetails("second", api.DeviceClaimDetail{
+					Constraints: ptr("pool.resources.memory >= quantity('80Gi')"),
+				}),
+			},
+			pools:         []api.DevicePool{partitionedPool()},
+			expectSuccess: false,
+		},
+		"pool resources left by an earlier claim": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("first", api.DeviceClaimDetail{
+					Constraints: ptr("device.capacity.memory == quantity('40Gi')"),
+				}),
+				claimWithDetails("second", api.DeviceClaimDetail{
+					Constraints: ptr("device.capacity.memory == quantity('40Gi') && pool.resources.memory >= quantity('40Gi')"),
+				}),
+			},
+			pools:            []api.DevicePool{partitionedPool()},
+			expectSuccess:    true,
+			expectNode:       "node-00",
+			expectDeviceSize: 2,
+			expectDevices:    []string{"half-0", "half-1"},
+		},
+		"pool resources enforced for an unconstrained claim": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("first", api.DeviceClaimDetail{
+					Constraints: ptr("device.capacity.memory == quantity('40Gi')"),
+				}),
+				claimWithDetails("second", api.DeviceClaimDetail{}),
+			},
+			pools:            []api.DevicePool{partitionedPool()},
+			expectSuccess:    true,
+			expectNode:       "node-00",
+			expectDeviceSize: 2,
+			expectDevices:    []string{"half-0", "half-1"},
+		},
+		"pool resources enforced within a claim": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					Requests: count("2"),
+				}),
+			},
+			pools:            []api.DevicePool{partitionedPool()},
+			expectSuccess:    true,
+			expectNode:       "node-00",
+			expectDeviceSize: 2,
+			expectDevices:    []string{"half-0", "half-1"},
+		},
+		"pool resources enforced across instances": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{}, api.DeviceClaimDetail{}, api.DeviceClaimDetail{}),
+			},
+			pools:         []api.DevicePool{partitionedPool()},
+			expectSuccess: false,
+		},
+		"invalid count": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					Requests: count("0"),
+				}),
+			},
+			pools:         mixedPools,
+			expectSuccess: false,
+		},
+	}
+
+	for tn, tc := range testCases {
+		verbose := os.Getenv("VERBOSE") == "y"
+
+		t.Run(tn, func(t *testing.T) {
+			allocations, results := SelectNode(tc.claims, tc.privilegedClaims, Cluster{
+				Drivers:     drivers,
+				Classes:     classes,
+				Pools:       tc.pools,
+				Allocations: tc.allocations,
+			})
+			b, _ := yaml.Marshal(allocations)
+			fmt.Println()
+			fmt.Println("=== TEST " + tn)
+			fmt.Println()
+			fmt.Println("ALLOCATIONS")
+			fmt.Println("-----------")
+			fmt.Println(string(b))
+			fmt.Println("NODE RESULTS")
+			fmt.Println("------------")
+			if verbose {
+				b, _ = yaml.Marshal(results)
+				fmt.Println(string(b))
+			} else {
+				for _, nr := range results {
+					fmt.Println(nr.Summary())
+				}
+			}
+			fmt.Println()
+			fmt.Println("=== DONE " + tn)
+			fmt.Println()
+
+			require.Equal(t, tc.expectSuccess, allocations != nil)
+			if !tc.expectSuccess {
+				return
+			}
+
+			require.Len(t, allocations, tc.expectDeviceSize)
+			for i, a := range allocations {
+				require.Equal(t, tc.expectNode+"-foozer", a.DevicePoolName)
+				if tc.expectDevices != nil {
+					require.Equal(t, tc.expectDevices[i], a.DeviceName)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectNodeClassConstraints(t *testing.T) {
+	classes := []api.DeviceClass{
+		class("foozer-4000", "example.com-foozer", ptr("device.model == 'foozer-4000'")),
+		class("barzer", "example.com-barzer", nil),
+	}
+
+	// The first node has foozer-1000 devices, which do not meet the class
+	// constraints, and the second has foozer-4000 devices, which do.
+	pools := append(gen.Gen("foozer-1000-small", 1), gen.Gen("foozer-4000-small", 1)...)
+
+	testCases := map[string]struct {
+		detail        api.DeviceClaimDetail
+		node          int
+		expectFailure string
+		expectPools   []PoolResult
+	}{
+		"rejected by class": {
+			detail: api.DeviceClaimDetail{
+				DeviceClass: ptr("foozer-4000"),
+				Constraints: ptr("device.numa == '0'"),
+			},
+			node:          0,
+			expectFailure: `device class "foozer-4000" constraints not met`,
+		},
+		"rejected by claim": {
+			detail: api.DeviceClaimDetail{
+				DeviceClass: ptr("foozer-4000"),
+				Constraints: ptr("device.numa == '1'"),
+			},
+			node:          1,
+			expectFailure: "claim constraints not met",
+		},
+		"error in claim": {
+			detail: api.DeviceClaimDetail{
+				DeviceClass: ptr("foozer-4000"),
+				Constraints: ptr("device.cores > 1"),
+			},
+			node:          1,
+			expectFailure: "error evaluating claim constraints: no such key: cores",
+		},
+		"rejected by class driver": {
+			detail: api.DeviceClaimDetail{
+				DeviceClass: ptr("barzer"),
+			},
+			node: 0,
+			expectPools: []PoolResult{{
+				PoolName:      "foozer-1000-small-00-foozer",
+				FailureReason: `driver "example.com-foozer" does not match driver "example.com-barzer" of device class "barzer"`,
+			}},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			claims := []api.DeviceClaim{claimWithDetails("myclaim", tc.detail)}
+			_, results := SelectNode(claims, nil, Cluster{Classes: classes, Pools: pools})
+			require.Len(t, results, 2)
+
+			ir := results[tc.node].DeviceClaimResults[0].InstanceResults[0]
+			if tc.expectPools != nil {
+				require.Equal(t, tc.expectPools, ir.IgnoredPools)
+				require.Empty(t, ir.IgnoredDevices)
+				return
+			}
+
+			require.Empty(t, ir.IgnoredPools)
+			require.NotEmpty(t, ir.IgnoredDevices)
+			require.Equal(t, "dev-00", ir.IgnoredDevices[0].DeviceName)
+			require.Equal(t, tc.expectFailure, ir.IgnoredDevices[0].FailureReason)
+		})
+	}
+}
+
+func TestSelectNodeDeviceTypeClasses(t *testing.T) {
+	drivers := []api.DeviceDriver{
+		driver("example.com-foozer", "gpu"),
+		driver("example.com-barzer", "gpu"),
+	}
+
+	// The first node has foozer-1000 devices, and the second has
+	// foozer-4000 devices.
+	pools := append(gen.Gen("foozer-1000-small", 1), gen.Gen("foozer-4000-small", 1)...)
+
+	testCases := map[string]struct {
+		classes       []api.DeviceClass
+		expectNodes   []bool
+		expectFailure string
+		expectPools   []PoolResult
+	}{
+		"no classes for the type": {
+			expectNodes: []bool{true, true},
+		},
+		"rejected by class": {
+			classes: []api.DeviceClass{
+				class("foozer-4000", "example.com-foozer", ptr("device.model == 'foozer-4000'")),
+			},
+			expectNodes:   []bool{false, true},
+			expectFailure: `constraints of the device classes for device type "gpu" not met`,
+		},
+		"met by any class": {
+			classes: []api.DeviceClass{
+				class("foozer-4000", "example.com-foozer", ptr("device.model == 'foozer-4000'")),
+				class("foozer-1000", "", ptr("device.model == 'foozer-1000'")),
+			},
+			expectNodes: []bool{true, true},
+		},
+		"class for another driver": {
+			classes: []api.DeviceClass{
+				class("barzer", "example.com-barzer", nil),
+			},
+			expectNodes: []bool{false, false},
+			expectPools: []PoolResult{{
+				PoolName:      "foozer-1000-small-00-foozer",
+				FailureReason: `no device class for device type "gpu" serves driver "example.com-foozer"`,
+			}},
+		},
+		"class for a driver without the type": {
+			classes: []api.DeviceClass{
+				class("foozer-4000", "example.com-foozer", ptr("device.model == 'foozer-4000'")),
+				class("nic", "example.com-nic", nil),
+			},
+			expectNodes:   []bool{false, true},
+			expectFailure: `constraints of the device classes for device type "gpu" not met`,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			claims := []api.DeviceClaim{claimWithDetails("myclaim", api.DeviceClaimDetail{DeviceType: ptr("gpu")})}
+			_, results := SelectNode(claims, nil, Cluster{Drivers: drivers, Classes: tc.classes, Pools: pools})
+			require.Len(t, results, 2)
+
+			for i, expect := range tc.expectNodes {
+				require.Equal(t, expect, results[i].Score() > 0, "node %d", i)
+			}
+
+			ir := results[0].DeviceClaimResults[0].InstanceResults[0]
+			if tc.expectPools != nil {
+				require.Equal(t, tc.expectPools, ir.IgnoredPools)
+				require.Empty(t, ir.IgnoredDevices)
+				return
+			}
+
+			require.Empty(t, ir.IgnoredPools)
+			if tc.expectFailure != "" {
+				require.NotEmpty(t, ir.IgnoredDevices)
+				require.Equal(t, tc.expectFailure, ir.IgnoredDevices[0].FailureReason)
+			}
+		})
+	}
+}
+
+func TestSelectNodeOneOf(t *testing.T) {
+	claims := []api.DeviceClaim{
+		claimWithOneOf("myclaim",
+			api.DeviceClaimDetail{Constraints: ptr("device.model == 'foozer-4000'")},
+			api.DeviceClaimDetail{Constraints: ptr("device.model == 'foozer-8000'")},
+			api.DeviceClaimDetail{Requests: count("2")},
+		),
+	}
+
+	_, results := SelectNode(claims, nil, Cluster{
+		Pools: append(gen.Gen("foozer-1000-small", 1), gen.Gen("foozer-4000-small", 1)...),
+	})
+	require.Len(t, results, 2)
+
+	// The first node only has foozer-1000 devices, so it falls back to the
+	// last alternative.
+	ir := results[0].DeviceClaimResults[0].InstanceResults[0]
+	require.Equal(t, ptr(2), ir.Alternative)
+	require.Equal(t, 33, ir.Score)
+	require.Len(t, ir.Allocations, 2)
+	require.Len(t, ir.FailedAlternatives, 2)
+	require.Equal(t, "unable to satisfy request for 1 devices from 0 candidates", ir.FailedAlternatives[0].FailureReason)
+
+	ir = results[1].DeviceClaimResults[0].InstanceResults[0]
+	require.Equal(t, ptr(0), ir.Alternative)
+	require.Equal(t, 100, ir.Score)
+	require.Len(t, ir.Allocations, 1)
+	require.Empty(t, ir.FailedAlternatives)
+
+	require.Equal(t, "foozer-4000-small-00", BestNode(results).NodeName)
+
+	// None of the alternatives can be satisfied.
+	claims = []api.DeviceClaim{
+		claimWithOneOf("myclaim",
+			api.DeviceClaimDetail{Constraints: ptr("device.model == 'foozer-8000'")},
+			api.DeviceClaimDetail{Requests: count("5")},
+		),
+	}
+	_, results = SelectNode(claims, nil, Cluster{Pools: gen.Gen("foozer-1000-small", 1)})
+	ir = results[0].DeviceClaimResults[0].InstanceResults[0]
+	require.Nil(t, ir.Alternative)
+	require.Equal(t, 0, ir.Score)
+	require.Equal(t, "none of the 2 alternatives could be satisfied", ir.FailureReason)
+	require.Len(t, ir.FailedAlternatives, 2)
+}
+
+func TestSelectNodeMatchAttributes(t *testing.T) {
+	gpu := api.DeviceClaimDetail{Constraints: ptr("device.driver == 'example.com-foozer'")}
+	nic := api.DeviceClaimDetail{Constraints: ptr("device.driver == 'example.com-nic'")}
+
+	testCases := map[string]struct {
+		matchAttributes []string
+		details         []api.DeviceClaimDetail
+		oneOf           []api.DeviceClaimDetail
+		expectDevices   []string
+		expectFailure   string
+	}{
+		"one pair matches": {
+			// Only gpu-1 and nic-1 have both the same NUMA node and
+			// the same PCIe root complex, so the first GPU cannot
+			// be used.
+			matchAttributes: []string{"numa", "pcie-root"},
+			details:         []api.DeviceClaimDetail{gpu, nic},
+			expectDevices:   []string{"gpu-1", "nic-1"},
+		},
+		"first pair matches": {
+			matchAttributes: []string{"pcie-root"},
+			details:         []api.DeviceClaimDetail{gpu, nic},
+			expectDevices:   []string{"gpu-0", "nic-0"},
+		},
+		"match searches all values": {
+			// The first GPU with NUMA node 1 is the third one, but
+			// the first NIC with it is the first one.
+			matchAttributes: []string{"numa"},
+			details: []api.DeviceClaimDetail{
+				gpu,
+				{Constraints: ptr("device.driver == 'example.com-nic'"), Requests: count("2")},
+			},
+			expectDevices: []string{"gpu-2", "nic-0", "nic-2"},
+		},
+		"devices are not shared between instances": {
+			matchAttributes: []string{"numa"},
+			details:         []api.DeviceClaimDetail{gpu, gpu},
+			expectDevices:   []string{"gpu-0", "gpu-1"},
+		},
+		"no values match": {
+			// The only NIC on pci-0 is on a different NUMA node from
+			// the GPU on pci-0.
+			matchAttributes: []string{"numa", "pcie-root"},
+			details:         []api.DeviceClaimDetail{gpu, {Constraints: ptr("device.driver == 'example.com-nic' && device['pcie-root'] == 'pci-0'")}},
+			expectFailure:   "unable to satisfy all of the instances with the same values for numa, pcie-root",
+		},
+		"devices without the attribute cannot match": {
+			matchAttributes: []string{"model"},
+			details:         []api.DeviceClaimDetail{gpu, nic},
+			expectFailure:   "unable to satisfy all of the instances with the same values for model",
+		},
+		"one of with match": {
+			// The first alternative cannot match the GPU, so the
+			// second is used.
+			matchAttributes: []string{"numa", "pcie-root"},
+			details:         []api.DeviceClaimDetail{{Constraints: ptr("device.driver == 'example.com-foozer' && device.numa == 1")}},
+			oneOf: []api.DeviceClaimDetail{
+				{Constraints: ptr("device.driver == 'example.com-nic' && device.numa == 0")},
+				{Constraints: ptr("device.driver == 'example.com-nic' && device.numa == 1")},
+			},
+			expectDevices: []string{"gpu-2", "nic-2"},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			claim := claimWithDetails("myclaim", tc.details...)
+			claim.Spec.MatchAttributes = tc.matchAttributes
+			if tc.oneOf != nil {
+				claim.Spec.Claims = append(claim.Spec.Claims, api.DeviceClaimInstance{OneOf: tc.oneOf})
+			}
+
+			allocations, results := SelectNode([]api.DeviceClaim{claim}, nil, Cluster{Pools: gpuAndNICPools()})
+			require.Len(t, results, 1)
+
+			dcr := results[0].DeviceClaimResults[0]
+			require.Equal(t, tc.expectFailure, dcr.FailureReason)
+			if tc.expectFailure != "" {
+				require.Nil(t, allocations)
+				require.Equal(t, 0, dcr.Score())
+				for _, ir := range dcr.InstanceResults {
+					require.Empty(t, ir.Allocations)
+					require.NotEmpty(t, ir.FailureReason)
+				}
+				return
+			}
+
+			var devices []string
+			for _, a := range allocations {
+				devices = append(devices, a.DeviceName)
+			}
+			require.Equal(t, tc.expectDevices, devices)
+		})
+	}
+}
+
+// vfPool returns a pool of SR-IOV VFs on a node, each of which is on a
+// physical function and a NUMA node. A VF without a physical function has an
+// empty pf.
+func vfPool(vfs ...[3]string) api.DevicePool {
+	pool := api.DevicePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-00-nic"},
+		Spec: api.DevicePoolSpec{
+			NodeName:   ptr("node-00"),
+			Driver:     "example.com-nic",
+			Attributes: []api.Attribute{{Name: "vendor", StringValue: ptr("example.com")}},
+		},
+	}
+
+	for _, vf := range vfs {
+		d := api.Device{Name: vf[0], Attributes: []api.Attribute{{Name: "numa", StringValue: ptr(vf[2])}}}
+		if vf[1] != "" {
+			d.Attributes = append(d.Attributes, api.Attribute{Name: "pf", StringValue: ptr(vf[1])})
+		}
+		pool.Spec.Devices = append(pool.Spec.Devices, d)
+	}
+
+	return pool
+}
+
+func TestSelectNodeDistinctAttributes(t *testing.T) {
+	pool := vfPool(
+		[3]string{"vf-0", "pf-0", "0"},
+		[3]string{"vf-1", "pf-0", "0"},
+		[3]string{"vf-2", "pf-1", "0"},
+		[3]string{"vf-3", "pf-1", "1"},
+		[3]string{"vf-4", "pf-2", "1"},
+		[3]string{"vf-5", "", "1"},
+	)
+
+	vfs := func(n string, distinctAttrs ...string) api.DeviceClaimDetail {
+		return api.DeviceClaimDetail{Requests: count(n), DistinctAttributes: distinctAttrs}
+	}
+
+	testCases := map[string]struct {
+		pool               api.DevicePool
+		matchAttributes    []string
+		distinctAttributes []string
+		details            []api.DeviceClaimDetail
+		expectDevices      []string
+	}{
+		"distinct": {
+			details:       []api.DeviceClaimDetail{vfs("3", "pf")},
+			expectDevices: []string{"vf-0", "vf-2", "vf-4"},
+		},
+		"not enough distinct values": {
+			// There are four VFs on different physical functions
+			// only if the one without a physical function counts,
+			// which it does not.
+			details: []api.DeviceClaimDetail{vfs("4", "pf")},
+		},
+		"device without the attribute": {
+			// A device without the attribute cannot be chosen,
+			// even on its own, since nothing says that it is
+			// distinct.
+			details: []api.DeviceClaimDetail{{
+				Constraints:        ptr("!device.hasAttribute('pf')"),
+				DistinctAttributes: []string{"pf"},
+			}},
+		},
+		"device without the attribute and no distinct attributes": {
+			details: []api.DeviceClaimDetail{{
+				Constraints: ptr("!device.hasAttribute('pf')"),
+			}},
+			expectDevices: []string{"vf-5"},
+		},
+		"distinct with match": {
+			details: []api.DeviceClaimDetail{{
+				Requests:           count("2"),
+				MatchAttributes:    []string{"numa"},
+				DistinctAttributes: []string{"pf"},
+			}},
+			expectDevices: []string{"vf-0", "vf-2"},
+		},
+		"not enough distinct values with match": {
+			// Each NUMA node has VFs on only two physical
+			// functions; on NUMA node 1, there would be three if
+			// vf-5 counted.
+			details: []api.DeviceClaimDetail{{
+				Requests:           count("3"),
+				MatchAttributes:    []string{"numa"},
+				DistinctAttributes: []string{"pf"},
+			}},
+		},
+		"two distinct attributes need backtracking": {
+			// Taking vf-0 first rules out both of the others, but
+			// vf-1 and vf-2 are distinct.
+			pool: vfPool(
+				[3]string{"vf-0", "pf-0", "0"},
+				[3]string{"vf-1", "pf-1", "0"},
+				[3]string{"vf-2", "pf-0", "1"},
+			),
+			details:       []api.DeviceClaimDetail{vfs("2", "pf", "numa")},
+			expectDevices: []string{"vf-1", "vf-2"},
+		},
+		"distinct across instances": {
+			distinctAttributes: []string{"pf"},
+			details:            []api.DeviceClaimDetail{vfs("1"), vfs("1"), vfs("1")},
+			expectDevices:      []string{"vf-0", "vf-2", "vf-4"},
+		},
+		"distinct across instances applies within each instance": {
+			distinctAttributes: []string{"pf"},
+			details:            []api.DeviceClaimDetail{vfs("2"), vfs("1")},
+			expectDevices:      []string{"vf-0", "vf-2", "vf-4"},
+		},
+		"not enough distinct values across instances": {
+			distinctAttributes: []string{"pf"},
+			details:            []api.DeviceClaimDetail{vfs("2"), vfs("2")},
+		},
+		"distinct and match across instances": {
+			matchAttributes:    []string{"numa"},
+			distinctAttributes: []string{"pf"},
+			details:            []api.DeviceClaimDetail{vfs("1"), {Constraints: ptr("device.pf == 'pf-2'")}},
+			expectDevices:      []string{"vf-3", "vf-4"},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			p := pool
+			if tc.pool.Name != "" {
+				p = tc.pool
+			}
+
+			claim := claimWithDetails("myclaim", tc.details...)
+			claim.Spec.MatchAttributes = tc.matchAttributes
+			claim.Spec.DistinctAttributes = tc.distinctAttributes
+
+			allocations, _ := SelectNode([]api.DeviceClaim{claim}, nil, Cluster{Pools: []api.DevicePool{p}})
+			if tc.expectDevices == nil {
+				require.Nil(t, allocations)
+				return
+			}
+
+			var devices []string
+			for _, a := range allocations {
+				devices = append(devices, a.DeviceName)
+			}
+			require.Equal(t, tc.expectDevices, devices)
+		})
+	}
+}
+
+func TestSelectNodeSharedDevice(t *testing.T) {
+	memory := func(q string) map[string]resource.Quantity {
+		return map[string]resource.Quantity{"memory": resource.MustParse(q)}
+	}
+	memoryAllocation := func(q string) []api.ResourceAllocation {
+		return []api.ResourceAllocation{{Name: "memory", Allocation: resource.MustParse(q)}}
+	}
+
+	// The memory of the device is allocated in blocks of 4Gi.
+	pool := api.DevicePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-00-foozer"},
+		Spec: api.DevicePoolSpec{
+			NodeName: ptr("node-00"),
+			Driver:   "example.com-foozer",
+			Devices: []api.Device{{
+				Name: "gpu-0",
+				Resources: []api.ResourceCapacity{
+					{Name: "memory", Capacity: resource.MustParse("16Gi"), BlockSize: ptr(resource.MustParse("4Gi"))},
+				},
+			}},
+		},
+	}
+
+	testCases := map[string]struct {
+		claims        []api.DeviceClaim
+		allocations   []api.DeviceAllocation
+		expectMemory  []string
+		expectFailure string
+	}{
+		"allocated twice": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("first", api.DeviceClaimDetail{Requests: memory("6Gi")}),
+				claimWithDetails("second", api.DeviceClaimDetail{Requests: memory("8Gi")}),
+			},
+			expectMemory: []string{"8Gi", "8Gi"},
+		},
+		"exhausted by earlier claims": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("first", api.DeviceClaimDetail{Requests: memory("6Gi")}),
+				claimWithDetails("second", api.DeviceClaimDetail{Requests: memory("8Gi")}),
+				claimWithDetails("third", api.DeviceClaimDetail{Requests: memory("1Gi")}),
+			},
+			expectFailure: "not enough device resources left for the claim",
+		},
+		"shared with an existing allocation": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{Requests: memory("4Gi")}),
+			},
+			allocations: []api.DeviceAllocation{
+				{DevicePoolName: "node-00-foozer", DeviceName: "gpu-0", Allocations: memoryAllocation("12Gi")},
+			},
+			expectMemory: []string{"4Gi"},
+		},
+		"exhausted by an existing allocation": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{Requests: memory("5Gi")}),
+			},
+			allocations: []api.DeviceAllocation{
+				{DevicePoolName: "node-00-foozer", DeviceName: "gpu-0", Allocations: memoryAllocation("12Gi")},
+			},
+			expectFailure: "not enough device resources left for the claim",
+		},
+		"whole device after sharing": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("first", api.DeviceClaimDetail{Requests: memory("4Gi")}),
+				claimWithDetails("second", api.DeviceClaimDetail{}),
+			},
+			expectFailure: "device is already allocated",
+		},
+		"sharing after whole device": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{Requests: memory("4Gi")}),
+			},
+			allocations:   allocations("node-00-foozer", "gpu-0"),
+			expectFailure: "device is already allocated",
+		},
+		"unknown resource": {
+			claims: []api.DeviceClaim{
+				claimWithDetails("myclaim", api.DeviceClaimDetail{
+					Requests: map[string]resource.Quantity{"cores": resource.MustParse("1")},
+				}),
+			},
+			expectFailure: "not enough device resources left for the claim",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			allocations, results := SelectNode(tc.claims, nil, Cluster{
+				Pools:       []api.DevicePool{pool},
+				Allocations: tc.allocations,
+			})
+
+			if tc.expectFailure != "" {
+				require.Nil(t, allocations)
+				require.Len(t, results, 1)
+				dcrs := results[0].DeviceClaimResults
+				ir := dcrs[len(dcrs)-1].InstanceResults[0]
+				require.Len(t, ir.IgnoredDevices, 1)
+				require.Equal(t, tc.expectFailure, ir.IgnoredDevices[0].FailureReason)
+				return
+			}
+
+			require.Len(t, allocations, len(tc.expectMemory))
+			for i, a := range allocations {
+				require.Equal(t, "gpu-0", a.DeviceName)
+				require.Len(t, a.Allocations, 1)
+				require.Equal(t, "memory", a.Allocations[0].Name)
+				require.Equal(t, tc.expectMemory[i], a.Allocations[0].Allocation.String())
+			}
+		})
+	}
+}
+
+func TestSelectNodeExplain(t *testing.T) {
+	claims := []api.DeviceClaim{
+		claimWithDetails("myclaim", api.DeviceClaimDetail{
+			Constraints: ptr("device.model == 'foozer-4000' && pool.nodeName == 'foozer-1000-small-01'"),
+		}),
+	}
+
+	for _, explain := range []bool{false, true} {
+		_, results := SelectNode(claims, nil, Cluster{
+			Pools:   gen.Gen("foozer-1000-small", 2),
+			Explain: explain,
+		})
+		require.Len(t, results, 2)
+
+		ir := results[0].DeviceClaimResults[0].InstanceResults[0]
+		require.NotEmpty(t, ir.IgnoredDevices)
+		dr := ir.IgnoredDevices[0]
+		require.Equal(t, "claim constraints not met", dr.FailureReason)
+		if !explain {
+			require.Nil(t, dr.FailedConstraints)
+			continue
+		}
+
+		require.Equal(t, []ConstraintFailure{
+			{
+				Clause: `device.model == "foozer-4000"`,
+				Values: map[string]string{"device.model": `"foozer-1000"`},
+			},
+			{
+				Clause: `pool.nodeName == "foozer-1000-small-01"`,
+				Values: map[string]string{"pool.nodeName": `"foozer-1000-small-00"`},
+			},
+		}, dr.FailedConstraints)
+
+		// Only the clause that is not about the node fails on the other
+		// node.
+		dr = results[1].DeviceClaimResults[0].InstanceResults[0].IgnoredDevices[0]
+		require.Len(t, dr.FailedConstraints, 1)
+		require.Equal(t, `device.model == "foozer-4000"`, dr.FailedConstraints[0].Clause)
+	}
+}
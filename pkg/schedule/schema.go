@@ -0,0 +1,134 @@
+package schedule
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+)
+
+// DeviceType is the CEL type of the device variable in an environment built
+// from a driver's attribute schema.
+var DeviceType = cel.ObjectType("k8srm.Device")
+
+// CompileConstraintsForDriver checks that the constraints expression is valid
+// CEL that evaluates to a bool, with the device attributes typed according to
+// the given schema. Referring to an attribute that is not in the schema, or
+// using an attribute as the wrong type, is an error. If the schema is empty,
+// the attributes are not typed, as with CompileConstraints.
+func CompileConstraintsForDriver(constraints string, schema []api.AttributeSchema) error {
+	if len(schema) == 0 {
+		return CompileConstraints(constraints)
+	}
+
+	env, err := schemaEnv(schema)
+	if err != nil {
+		return err
+	}
+
+	_, err = compileExprInEnv(env, constraints)
+	return err
+}
+
+// schemaEnv returns an environment in which the device is an object with a
+// field for each attribute in the schema, plus the driver and resources.
+func schemaEnv(schema []api.AttributeSchema) (*cel.Env, error) {
+	fields := make(map[string]*cel.Type, len(schema)+3)
+	fields[DriverFieldName] = cel.StringType
+	fields[ResourcesFieldName] = cel.MapType(cel.StringType, QuantityType)
+	fields[CapacityFieldName] = cel.MapType(cel.StringType, QuantityType)
+	for _, a := range schema {
+		t, err := attributeCELType(a.Type)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %q: %w", a.Name, err)
+		}
+		fields[a.Name] = t
+	}
+
+	env, err := baseEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return env.Extend(
+		func(e *cel.Env) (*cel.Env, error) {
+			return cel.CustomTypeProvider(&deviceProvider{Provider: e.CELTypeProvider(), fields: fields})(e)
+		},
+		cel.Variable(DeviceVarName, DeviceType),
+	)
+}
+
+func attributeCELType(t api.AttributeType) (*cel.Type, error) {
+	switch t {
+	case api.AttributeTypeString:
+		return cel.StringType, nil
+	case api.AttributeTypeInt:
+		return cel.IntType, nil
+	case api.AttributeTypeQuantity:
+		return QuantityType, nil
+	case api.AttributeTypeSemVer:
+		return SemVerType, nil
+	}
+	return nil, fmt.Errorf("unknown attribute type %q", t)
+}
+
+// deviceProvider adds the fields of DeviceType, as given by an attribute
+// schema, to an existing type provider. The fields are read from the same
+// inputs as are used when the device is not typed, so a device need not have
+// every attribute; has() can be used to check for one.
+type deviceProvider struct {
+	types.Provider
+	fields map[string]*cel.Type
+}
+
+func (p *deviceProvider) FindStructType(structType string) (*types.Type, bool) {
+	if structType == DeviceType.TypeName() {
+		return types.NewTypeTypeWithParam(DeviceType), true
+	}
+	return p.Provider.FindStructType(structType)
+}
+
+func (p *deviceProvider) FindStructFieldNames(structType string) ([]string, bool) {
+	if structType != DeviceType.TypeName() {
+		return p.Provider.FindStructFieldNames(structType)
+	}
+	var names []string
+	for name := range p.fields {
+		names = append(names, name)
+	}
+	return names, true
+}
+
+func (p *deviceProvider) FindStructFieldType(structType, fieldName string) (*types.FieldType, bool) {
+	if structType != DeviceType.TypeName() {
+		return p.Provider.FindStructFieldType(structType, fieldName)
+	}
+	t, ok := p.fields[fieldName]
+	if !ok {
+		return nil, false
+	}
+	return &types.FieldType{
+		Type: t,
+		IsSet: func(obj any) bool {
+			attrs, ok := obj.(map[string]interface{})
+			if !ok {
+				return false
+			}
+			_, ok = attrs[fieldName]
+			return ok
+		},
+		GetFrom: func(obj any) (any, error) {
+			attrs, ok := obj.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("unexpected device value %T", obj)
+			}
+			v, ok := attrs[fieldName]
+			if !ok {
+				return nil, fmt.Errorf("no such attribute: %s", fieldName)
+			}
+			return v, nil
+		},
+	}, true
+}
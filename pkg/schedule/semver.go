@@ -0,0 +1,275 @@
+package schedule
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+)
+
+// SemVerType is the CEL type of a semantic version.
+var SemVerType = cel.ObjectType("k8srm.SemVer", traits.ComparerType)
+
+// SemVer is the CEL value of a semantic version. Versions are ordered as
+// described at https://semver.org, so a prerelease such as 4.2.1-gen3 sorts
+// before 4.2.1, and build metadata is ignored.
+type SemVer struct {
+	semver.Version
+}
+
+// ParseSemVer parses a version in the form MAJOR.MINOR.PATCH, optionally
+// followed by a prerelease and build metadata.
+func ParseSemVer(v api.SemVer) (SemVer, error) {
+	sv, err := semver.Parse(string(v))
+	if err != nil {
+		return SemVer{}, err
+	}
+	return SemVer{sv}, nil
+}
+
+var semVerReflectType = reflect.TypeOf(api.SemVer(""))
+
+// ConvertToNative implements ref.Val.
+func (v SemVer) ConvertToNative(typeDesc reflect.Type) (any, error) {
+	if typeDesc == semVerReflectType {
+		return api.SemVer(v.Version.String()), nil
+	}
+	return nil, fmt.Errorf("type conversion error from 'SemVer' to '%v'", typeDesc)
+}
+
+// ConvertToType implements ref.Val.
+func (v SemVer) ConvertToType(typeVal ref.Type) ref.Val {
+	switch typeVal {
+	case SemVerType:
+		return v
+	case types.StringType:
+		return types.String(v.Version.String())
+	case types.TypeType:
+		return SemVerType
+	}
+	return types.NewErr("type conversion error from '%s' to '%s'", SemVerType, typeVal)
+}
+
+// Equal implements ref.Val. Like Compare, it accepts a SemVer or a String,
+// which is parsed as a version. Other values are never equal.
+func (v SemVer) Equal(other ref.Val) ref.Val {
+	switch other.(type) {
+	case SemVer, types.String:
+		return applyOp(v.Compare(other), func(cmp int) bool { return cmp == 0 })
+	}
+	return types.False
+}
+
+// Type implements ref.Val.
+func (v SemVer) Type() ref.Type {
+	return SemVerType
+}
+
+// Value implements ref.Val.
+func (v SemVer) Value() any {
+	return v.Version
+}
+
+// Compare implements traits.Comparer. The other value may be a SemVer or a
+// String, which is parsed as a version.
+func (v SemVer) Compare(other ref.Val) ref.Val {
+	switch o := other.(type) {
+	case SemVer:
+		return types.Int(v.Version.Compare(o.Version))
+	case types.String:
+		ov, err := ParseSemVer(api.SemVer(o))
+		if err != nil {
+			return types.NewErr("invalid semantic version %q: %v", string(o), err)
+		}
+		return types.Int(v.Version.Compare(ov.Version))
+	}
+	return types.MaybeNoSuchOverloadErr(other)
+}
+
+// Get implements traits.Indexer, so that the fields can be selected when the
+// type is not known until runtime, as with device attributes.
+func (v SemVer) Get(index ref.Val) ref.Val {
+	name, ok := index.(types.String)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(index)
+	}
+	f, ok := semVerFields[string(name)]
+	if !ok {
+		return types.NewErr("no such field '%s' on %s", name, SemVerType)
+	}
+	return types.DefaultTypeAdapter.NativeToValue(f.get(v.Version))
+}
+
+// String returns the version, for use in messages.
+func (v SemVer) String() string {
+	return v.Version.String()
+}
+
+// semVerFields are the fields that can be selected from a SemVer; e.g.,
+// semver('1.8.2').major.
+var semVerFields = map[string]struct {
+	typ *cel.Type
+	get func(semver.Version) any
+}{
+	"major": {cel.IntType, func(v semver.Version) any { return int64(v.Major) }},
+	"minor": {cel.IntType, func(v semver.Version) any { return int64(v.Minor) }},
+	"patch": {cel.IntType, func(v semver.Version) any { return int64(v.Patch) }},
+	"prerelease": {cel.StringType, func(v semver.Version) any {
+		var pre []string
+		for _, p := range v.Pre {
+			pre = append(pre, p.String())
+		}
+		return strings.Join(pre, ".")
+	}},
+	"build": {cel.StringType, func(v semver.Version) any { return strings.Join(v.Build, ".") }},
+}
+
+// SemVerLib makes the SemVer type available in expressions, along with:
+//
+//   - semver(), which parses a version from a string
+//   - the major, minor, patch, prerelease and build fields
+//   - the comparison operators, and the isGreaterThan(), isLessThan() and
+//     compareTo() methods
+//
+// A version may also be compared to a string on either side of the comparison
+// operators, in which case the string is parsed as a version; e.g.,
+// device.firmwareVersion >= '1.10.0' or '1.9.0' < device.firmwareVersion.
+// When the device attributes are not typed, == and != also compare a version
+// and a string this way. When they are typed, such an expression does not
+// compile, since the CEL equality operators only apply to values of the same
+// type.
+func SemVerLib() cel.EnvOption {
+	return cel.Lib(semVerLib{})
+}
+
+type semVerLib struct{}
+
+func (semVerLib) LibraryName() string {
+	return "k8srm.semver"
+}
+
+func (semVerLib) CompileOptions() []cel.EnvOption {
+	// The operators only need declarations. At runtime, they are
+	// dispatched to the Compare method of SemVer, with the operands
+	// reversed if need be.
+	binary := func(name, op string, arg *cel.Type) cel.EnvOption {
+		return cel.Function(op, cel.Overload(name, []*cel.Type{SemVerType, arg}, cel.BoolType))
+	}
+	reversed := func(name, op string, arg *cel.Type) cel.EnvOption {
+		return cel.Function(op, cel.Overload(name, []*cel.Type{arg, SemVerType}, cel.BoolType))
+	}
+
+	return []cel.EnvOption{
+		func(e *cel.Env) (*cel.Env, error) {
+			return cel.CustomTypeProvider(&semVerProvider{Provider: e.CELTypeProvider()})(e)
+		},
+		cel.Function("semver",
+			cel.Overload("string_to_semver", []*cel.Type{cel.StringType}, SemVerType,
+				cel.UnaryBinding(stringToSemVer)),
+		),
+		cel.Function("isGreaterThan",
+			cel.MemberOverload("semver_is_greater_than", []*cel.Type{SemVerType, SemVerType}, cel.BoolType,
+				cel.BinaryBinding(func(lhs, rhs ref.Val) ref.Val {
+					return compareSemVer(lhs, rhs, func(c int) bool { return c > 0 })
+				})),
+		),
+		cel.Function("isLessThan",
+			cel.MemberOverload("semver_is_less_than", []*cel.Type{SemVerType, SemVerType}, cel.BoolType,
+				cel.BinaryBinding(func(lhs, rhs ref.Val) ref.Val {
+					return compareSemVer(lhs, rhs, func(c int) bool { return c < 0 })
+				})),
+		),
+		cel.Function("compareTo",
+			cel.MemberOverload("semver_compare_to", []*cel.Type{SemVerType, SemVerType}, cel.IntType,
+				cel.BinaryBinding(func(lhs, rhs ref.Val) ref.Val {
+					return lhs.(traits.Comparer).Compare(rhs)
+				})),
+		),
+		binary("less_semver", operators.Less, SemVerType),
+		binary("less_equals_semver", operators.LessEquals, SemVerType),
+		binary("greater_semver", operators.Greater, SemVerType),
+		binary("greater_equals_semver", operators.GreaterEquals, SemVerType),
+		binary("less_semver_string", operators.Less, cel.StringType),
+		binary("less_equals_semver_string", operators.LessEquals, cel.StringType),
+		binary("greater_semver_string", operators.Greater, cel.StringType),
+		binary("greater_equals_semver_string", operators.GreaterEquals, cel.StringType),
+		reversed("less_string_semver", operators.Less, cel.StringType),
+		reversed("less_equals_string_semver", operators.LessEquals, cel.StringType),
+		reversed("greater_string_semver", operators.Greater, cel.StringType),
+		reversed("greater_equals_string_semver", operators.GreaterEquals, cel.StringType),
+	}
+}
+
+func (semVerLib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{cel.CustomDecorator(decorateReversedOperators)}
+}
+
+func stringToSemVer(arg ref.Val) ref.Val {
+	s, ok := arg.(types.String)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(arg)
+	}
+
+	v, err := ParseSemVer(api.SemVer(s))
+	if err != nil {
+		return types.NewErr("invalid semantic version %q: %v", string(s), err)
+	}
+
+	return v
+}
+
+func compareSemVer(lhs, rhs ref.Val, f func(int) bool) ref.Val {
+	cmp := lhs.(traits.Comparer).Compare(rhs)
+	c, ok := cmp.(types.Int)
+	if !ok {
+		return cmp
+	}
+	return types.Bool(f(int(c)))
+}
+
+// semVerProvider adds the fields of SemVer to an existing type provider, so
+// that selecting them can be type-checked.
+type semVerProvider struct {
+	types.Provider
+}
+
+func (p *semVerProvider) FindStructType(structType string) (*types.Type, bool) {
+	if structType == SemVerType.TypeName() {
+		return types.NewTypeTypeWithParam(SemVerType), true
+	}
+	return p.Provider.FindStructType(structType)
+}
+
+func (p *semVerProvider) FindStructFieldNames(structType string) ([]string, bool) {
+	if structType != SemVerType.TypeName() {
+		return p.Provider.FindStructFieldNames(structType)
+	}
+	var names []string
+	for name := range semVerFields {
+		names = append(names, name)
+	}
+	return names, true
+}
+
+func (p *semVerProvider) FindStructFieldType(structType, fieldName string) (*types.FieldType, bool) {
+	if structType != SemVerType.TypeName() {
+		return p.Provider.FindStructFieldType(structType, fieldName)
+	}
+	f, ok := semVerFields[fieldName]
+	if !ok {
+		return nil, false
+	}
+	return &types.FieldType{
+		Type:    f.typ,
+		IsSet:   func(any) bool { return true },
+		GetFrom: func(obj any) (any, error) { return f.get(obj.(semver.Version)), nil },
+	}, true
+}
@@ -0,0 +1,164 @@
+package schedule
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+)
+
+// deviceKey uniquely identifies a device within the cluster.
+type deviceKey struct {
+	pool   string
+	device string
+}
+
+// allocationState tracks which devices are no longer available to ordinary
+// claims. Claims with admin access ignore it entirely.
+//
+// A device is allocated whole unless only some of its per-device resources
+// were allocated from it. Such a device may be shared by other claims for
+// the resources that are left.
+type allocationState struct {
+	// parent is the state that this one was forked from, if any. Its
+	// allocations are included in this one.
+	parent *allocationState
+
+	allocated map[deviceKey]bool
+
+	// whole is the set of allocated devices that cannot be shared.
+	whole map[deviceKey]bool
+
+	// resources is the amount of each per-device resource that has
+	// been allocated from each device.
+	resources map[deviceKey]map[string]resource.Quantity
+}
+
+// newAllocationState builds the state from allocations that have already been
+// made, for example from the status of existing DeviceClaims. Allocations for
+// claims with admin access must not be included, since those do not consume
+// the devices.
+func newAllocationState(allocations []api.DeviceAllocation) *allocationState {
+	s := &allocationState{
+		allocated: make(map[deviceKey]bool),
+		whole:     make(map[deviceKey]bool),
+		resources: make(map[deviceKey]map[string]resource.Quantity),
+	}
+	s.add(allocations)
+
+	return s
+}
+
+// fork returns a new state that starts out with the same allocations as this
+// one. Allocations added to the new state do not affect this one.
+func (s *allocationState) fork() *allocationState {
+	return &allocationState{
+		parent:    s,
+		allocated: make(map[deviceKey]bool),
+		whole:     make(map[deviceKey]bool),
+		resources: make(map[deviceKey]map[string]resource.Quantity),
+	}
+}
+
+// add records more allocations. As with newAllocationState, allocations for
+// claims with admin access must not be included.
+func (s *allocationState) add(allocations []api.DeviceAllocation) {
+	for _, a := range allocations {
+		key := deviceKey{pool: a.DevicePoolName, device: a.DeviceName}
+		s.allocated[key] = true
+		if len(a.Allocations) == 0 {
+			s.whole[key] = true
+		}
+
+		for _, ra := range a.Allocations {
+			if s.resources[key] == nil {
+				s.resources[key] = make(map[string]resource.Quantity)
+			}
+			addQuantity(s.resources[key], ra.Name, ra.Allocation)
+		}
+	}
+}
+
+func (s *allocationState) isAllocated(pool, device string) bool {
+	if s.allocated[deviceKey{pool: pool, device: device}] {
+		return true
+	}
+
+	return s.parent != nil && s.parent.isAllocated(pool, device)
+}
+
+func (s *allocationState) isAllocatedWhole(pool, device string) bool {
+	if s.whole[deviceKey{pool: pool, device: device}] {
+		return true
+	}
+
+	return s.parent != nil && s.parent.isAllocatedWhole(pool, device)
+}
+
+// deviceUsage returns the amount of each per-device resource that has been
+// allocated from the device.
+func (s *allocationState) deviceUsage(pool, device string) map[string]resource.Quantity {
+	own := s.resources[deviceKey{pool: pool, device: device}]
+	if s.parent == nil {
+		return own
+	}
+
+	inherited := s.parent.deviceUsage(pool, device)
+	if len(own) == 0 {
+		return inherited
+	}
+
+	used := make(map[string]resource.Quantity)
+	for name, q := range inherited {
+		addQuantity(used, name, q)
+	}
+	for name, q := range own {
+		addQuantity(used, name, q)
+	}
+
+	return used
+}
+
+// poolUsage returns the amount of each pool resource that is consumed by the
+// allocated devices in the pool.
+func (s *allocationState) poolUsage(pool *api.DevicePool) map[string]resource.Quantity {
+	used := make(map[string]resource.Quantity)
+	for _, d := range pool.Spec.Devices {
+		if !s.isAllocated(pool.Name, d.Name) {
+			continue
+		}
+
+		for name, q := range d.Requests {
+			addQuantity(used, name, q)
+		}
+	}
+
+	return used
+}
+
+// poolFree returns the amount of each pool resource that is left once the
+// used amount is taken away. Resources the pool does not have are left out,
+// so that devices requesting them never fit.
+func poolFree(pool *api.DevicePool, used map[string]resource.Quantity) map[string]resource.Quantity {
+	return resourcesFree(pool.Spec.Resources, used)
+}
+
+// resourcesFree returns the amount of each resource that is left once the used
+// amount is taken away.
+func resourcesFree(resources []api.ResourceCapacity, used map[string]resource.Quantity) map[string]resource.Quantity {
+	free := make(map[string]resource.Quantity, len(resources))
+	for _, r := range resources {
+		q := r.Capacity.DeepCopy()
+		if u, ok := used[r.Name]; ok {
+			q.Sub(u)
+		}
+		free[r.Name] = q
+	}
+
+	return free
+}
+
+func addQuantity(m map[string]resource.Quantity, name string, q resource.Quantity) {
+	sum := m[name]
+	sum.Add(q)
+	m[name] = sum
+}
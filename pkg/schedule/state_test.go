@@ -0,0 +1,45 @@
+package schedule
+
+import (
+	"testing"
+
+	"github.com/johnbelamaric/k8srm-prototype/pkg/api"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestAllocationStateFork(t *testing.T) {
+	memory := func(q string) []api.ResourceAllocation {
+		return []api.ResourceAllocation{{Name: "memory", Allocation: resource.MustParse(q)}}
+	}
+
+	state := newAllocationState([]api.DeviceAllocation{
+		{DevicePoolName: "pool", DeviceName: "dev-00", Allocations: memory("10Gi")},
+	})
+
+	node := state.fork()
+	node.add([]api.DeviceAllocation{
+		{DevicePoolName: "pool", DeviceName: "dev-00", Allocations: memory("20Gi")},
+		{DevicePoolName: "pool", DeviceName: "dev-01", Allocations: memory("5Gi")},
+	})
+
+	// The fork sees its own allocations along with those it started with.
+	require.True(t, node.isAllocated("pool", "dev-00"))
+	require.True(t, node.isAllocated("pool", "dev-01"))
+	require.False(t, node.isAllocated("pool", "dev-02"))
+	require.False(t, node.isAllocatedWhole("pool", "dev-00"))
+	used := node.deviceUsage("pool", "dev-00")["memory"]
+	require.Equal(t, "30Gi", used.String())
+	used = node.deviceUsage("pool", "dev-01")["memory"]
+	require.Equal(t, "5Gi", used.String())
+
+	// The original, and other forks of it, do not.
+	for _, s := range []*allocationState{state, state.fork()} {
+		require.True(t, s.isAllocated("pool", "dev-00"))
+		require.False(t, s.isAllocated("pool", "dev-01"))
+		used = s.deviceUsage("pool", "dev-00")["memory"]
+		require.Equal(t, "10Gi", used.String())
+		require.Empty(t, s.deviceUsage("pool", "dev-01"))
+	}
+}